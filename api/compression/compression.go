@@ -0,0 +1,416 @@
+package compression
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/pkg/metrics"
+)
+
+// Content codings of responses and request bodies
+const (
+	Gzip     = "gzip"
+	Brotli   = "br"
+	Zstd     = "zstd"
+	Identity = "identity"
+)
+
+// maxDecoderMemory bounds the memory of a zstd request body decoder, the decoded size
+// is bounded by the body limit of the route
+const maxDecoderMemory = 64 << 20
+
+// ErrUnsupportedEncoding is returned for a request body of a content coding the gateway can not decode
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+var compressedTotal = metrics.NewCounter(
+	"http_compressed_responses_total",
+	"Responses compressed per content coding",
+	"encoding",
+)
+
+// Options configures the compression of responses
+type Options struct {
+	// Encodings are the content codings offered to Accept-Encoding, most preferred first,
+	// responses are not compressed when empty
+	Encodings []string
+	// MinSize is the size in bytes from which a response is compressed
+	MinSize int
+	// ContentTypes are the media types compressed, type/* matches every subtype
+	ContentTypes []string
+
+	GzipLevel   int
+	BrotliLevel int
+	ZstdLevel   int
+}
+
+// Compressor compresses responses with the coding the client prefers among the
+// offered ones and decompresses request bodies sent with a Content-Encoding
+type Compressor struct {
+	opts  Options
+	pools map[string]*sync.Pool
+}
+
+// encoder is a pooled compressing writer
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// New validates opts and returns the compressor
+func New(opts Options) (*Compressor, error) {
+	c := &Compressor{opts: opts, pools: map[string]*sync.Pool{}}
+
+	for _, encoding := range opts.Encodings {
+		var newEncoder func() encoder
+		switch encoding {
+		case Gzip:
+			if _, err := gzip.NewWriterLevel(io.Discard, opts.GzipLevel); err != nil {
+				return nil, fmt.Errorf("gzip level: %w", err)
+			}
+			newEncoder = func() encoder {
+				w, _ := gzip.NewWriterLevel(nil, opts.GzipLevel)
+				return w
+			}
+		case Brotli:
+			if opts.BrotliLevel < brotli.BestSpeed || opts.BrotliLevel > brotli.BestCompression {
+				return nil, fmt.Errorf("brotli level %d is not between %d and %d", opts.BrotliLevel, brotli.BestSpeed, brotli.BestCompression)
+			}
+			newEncoder = func() encoder {
+				return brotli.NewWriterLevel(nil, opts.BrotliLevel)
+			}
+		case Zstd:
+			if opts.ZstdLevel < 1 || opts.ZstdLevel > 22 {
+				return nil, fmt.Errorf("zstd level %d is not between 1 and 22", opts.ZstdLevel)
+			}
+			level := zstd.EncoderLevelFromZstd(opts.ZstdLevel)
+			newEncoder = func() encoder {
+				w, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+				return w
+			}
+		default:
+			return nil, fmt.Errorf("unknown encoding %q, must be one of %s, %s, %s", encoding, Brotli, Zstd, Gzip)
+		}
+		c.pools[encoding] = &sync.Pool{New: func() interface{} { return newEncoder() }}
+	}
+
+	return c, nil
+}
+
+// Middleware decompresses the request body and compresses the response
+func (cp *Compressor) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := cp.decodeBody(c.Request); err != nil {
+			code, message := http.StatusBadRequest, "invalid body"
+			if errors.Is(err, ErrUnsupportedEncoding) {
+				code, message = http.StatusUnsupportedMediaType, "unsupported content encoding"
+			}
+			c.AbortWithStatusJSON(code, models.ResponseModel{
+				Code:    code,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if len(cp.pools) == 0 {
+			c.Next()
+			return
+		}
+		// every response may be compressed for another Accept-Encoding, bodyless and 304 ones included
+		addVary(c.Writer.Header(), "Accept-Encoding")
+		if c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &writer{ResponseWriter: c.Writer, cp: cp, encoding: cp.negotiate(c.GetHeader("Accept-Encoding"))}
+		c.Writer = w
+		defer w.close()
+
+		c.Next()
+	}
+}
+
+// negotiate returns the offered coding with the highest quality in an Accept-Encoding
+// header, the most preferred offer on ties, "" when the response is sent as it is
+func (cp *Compressor) negotiate(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value := strings.TrimSpace(param); strings.HasPrefix(value, "q=") {
+				var err error
+				if q, err = strconv.ParseFloat(value[2:], 64); err != nil {
+					q = 0
+				}
+			}
+		}
+		qualities[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range cp.opts.Encodings {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
+
+// compressible reports whether responses of contentType are compressed
+func (cp *Compressor) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range cp.opts.ContentTypes {
+		if allowed == mediaType || strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeBody replaces a request body sent with a Content-Encoding by its decoded form,
+// codings applied one after the other are decoded in reverse order
+func (cp *Compressor) decodeBody(req *http.Request) error {
+	header := req.Header.Get("Content-Encoding")
+	if header == "" || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	codings := strings.Split(header, ",")
+	body := req.Body
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		var (
+			decoded io.ReadCloser
+			err     error
+		)
+		switch coding {
+		case Identity:
+			continue
+		case Gzip, "x-gzip":
+			decoded, err = gzip.NewReader(body)
+		case Brotli:
+			decoded = io.NopCloser(brotli.NewReader(body))
+		case Zstd:
+			var d *zstd.Decoder
+			if d, err = zstd.NewReader(body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxDecoderMemory)); err == nil {
+				decoded = d.IOReadCloser()
+			}
+		default:
+			return fmt.Errorf("%w %q, must be one of %s, %s, %s", ErrUnsupportedEncoding, coding, Gzip, Brotli, Zstd)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s body: %w", coding, err)
+		}
+		body = &decodedBody{Reader: &decodeErrors{r: decoded, coding: coding}, closers: []io.Closer{decoded, body}}
+	}
+
+	req.Body = body
+	req.ContentLength = -1
+	req.Header.Del("Content-Encoding")
+	req.Header.Del("Content-Length")
+
+	return nil
+}
+
+// decodedBody closes the decoder and the body it reads
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	var err error
+	for _, c := range b.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// decodeErrors names the coding in the errors of a decoder
+type decodeErrors struct {
+	r      io.Reader
+	coding string
+}
+
+func (d *decodeErrors) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("invalid %s body: %w", d.coding, err)
+	}
+
+	return n, err
+}
+
+// writer buffers the start of the response until it is known whether it is compressed:
+// once MinSize bytes are written, or when the handler is done
+type writer struct {
+	gin.ResponseWriter
+	cp       *Compressor
+	encoding string
+
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	if w.decided {
+		return w.write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) < w.cp.opts.MinSize {
+		return len(data), nil
+	}
+	if err := w.decide(); err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow sends the headers of a response without body, it is not compressed
+func (w *writer) WriteHeaderNow() {
+	if !w.decided {
+		w.decided = true
+		w.flushBuffer()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *writer) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *writer) write(data []byte) (int, error) {
+	if w.enc != nil {
+		return w.enc.Write(data)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+// decide picks the coding of the response from its headers and writes the buffer
+func (w *writer) decide() error {
+	w.decided = true
+
+	header := w.Header()
+	if w.cp.compressible(header.Get("Content-Type")) {
+		status := w.Status()
+		if w.encoding != "" && len(w.buf) >= w.cp.opts.MinSize && header.Get("Content-Encoding") == "" &&
+			status != http.StatusPartialContent && header.Get("Content-Range") == "" {
+			header.Set("Content-Encoding", w.encoding)
+			header.Del("Content-Length")
+			if tag := header.Get("ETag"); tag != "" {
+				header.Set("ETag", codingETag(tag, w.encoding))
+			}
+			w.enc = w.cp.pools[w.encoding].Get().(encoder)
+			w.enc.Reset(w.ResponseWriter)
+			compressedTotal.Inc(w.encoding)
+		}
+	}
+
+	return w.flushBuffer()
+}
+
+func (w *writer) flushBuffer() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	_, err := w.write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// close writes a response shorter than MinSize and ends the compressed stream
+func (w *writer) close() {
+	if !w.decided && len(w.buf) > 0 {
+		w.decide()
+	}
+	if w.enc == nil {
+		return
+	}
+
+	w.enc.Close()
+	w.enc.Reset(nil)
+	w.cp.pools[w.encoding].Put(w.enc)
+	w.enc = nil
+}
+
+// codingETag suffixes an entity tag with the content coding of the response, a compressed
+// representation is not byte for byte the uncompressed one and needs its own strong tag
+func codingETag(tag, encoding string) string {
+	if !strings.HasSuffix(tag, `"`) {
+		return tag
+	}
+
+	return strings.TrimSuffix(tag, `"`) + "-" + encoding + `"`
+}
+
+// ETagBase strips the content coding suffix from the entity tag of a compressed response,
+// conditional requests compare the tags of the uncompressed representations
+func ETagBase(tag string) string {
+	for _, encoding := range []string{Gzip, Brotli, Zstd} {
+		if suffix := "-" + encoding + `"`; strings.HasSuffix(tag, suffix) {
+			return strings.TrimSuffix(tag, suffix) + `"`
+		}
+	}
+
+	return tag
+}
+
+// addVary adds value to the Vary header unless it is listed already
+func addVary(header http.Header, value string) {
+	for _, line := range header.Values("Vary") {
+		for _, v := range strings.Split(line, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), value) {
+				return
+			}
+		}
+	}
+
+	header.Add("Vary", value)
+}
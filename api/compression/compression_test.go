@@ -0,0 +1,352 @@
+package compression
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+func newCompressor(t *testing.T) *Compressor {
+	t.Helper()
+	cp, err := New(Options{
+		Encodings:    []string{Brotli, Zstd, Gzip},
+		MinSize:      64,
+		ContentTypes: []string{"application/json", "text/*"},
+		GzipLevel:    6,
+		BrotliLevel:  4,
+		ZstdLevel:    3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cp
+}
+
+func TestNegotiate(t *testing.T) {
+	cp := newCompressor(t)
+
+	tests := []struct {
+		header, want string
+	}{
+		{header: "", want: ""},
+		{header: "gzip", want: Gzip},
+		{header: "gzip, br", want: Brotli},
+		{header: "gzip, zstd", want: Zstd},
+		{header: "br;q=0.5, gzip", want: Gzip},
+		{header: "BR", want: Brotli},
+		{header: "*", want: Brotli},
+		{header: "*, br;q=0", want: Zstd},
+		{header: "gzip;q=0", want: ""},
+		{header: "gzip;q=x", want: ""},
+		{header: "identity", want: ""},
+		{header: "deflate", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := cp.negotiate(tt.header); got != tt.want {
+				t.Errorf("negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func encode(t *testing.T, coding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch coding {
+	case Gzip:
+		w = gzip.NewWriter(&buf)
+	case Brotli:
+		w = brotli.NewWriter(&buf)
+	case Zstd:
+		var err error
+		if w, err = zstd.NewWriter(&buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecodeBody(t *testing.T) {
+	cp := newCompressor(t)
+	plain := []byte(`{"name":"acme"}`)
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		want     []byte
+		// err fails decodeBody, readErr reading the decoded body
+		err     error
+		readErr bool
+	}{
+		{name: "plain", body: plain, want: plain},
+		{name: "identity", encoding: "identity", body: plain, want: plain},
+		{name: "gzip", encoding: "gzip", body: encode(t, Gzip, plain), want: plain},
+		{name: "x-gzip", encoding: "x-gzip", body: encode(t, Gzip, plain), want: plain},
+		{name: "brotli", encoding: "br", body: encode(t, Brotli, plain), want: plain},
+		{name: "zstd", encoding: "ZSTD", body: encode(t, Zstd, plain), want: plain},
+		{name: "in order", encoding: "zstd, gzip", body: encode(t, Gzip, encode(t, Zstd, plain)), want: plain},
+		{name: "unsupported", encoding: "deflate", body: plain, err: ErrUnsupportedEncoding},
+		{name: "invalid gzip", encoding: "gzip", body: plain, err: errors.New("invalid gzip body")},
+		{name: "truncated gzip", encoding: "gzip", body: encode(t, Gzip, plain)[:20], readErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			err := cp.decodeBody(req)
+			if tt.err != nil {
+				if err == nil || !errors.Is(err, tt.err) && !strings.Contains(err.Error(), tt.err.Error()) {
+					t.Fatalf("decodeBody error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeBody: %v", err)
+			}
+
+			got, err := io.ReadAll(req.Body)
+			if tt.readErr {
+				if err == nil {
+					t.Fatal("reading the body succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reading the body: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("body %q, want %q", got, tt.want)
+			}
+			if tt.encoding != "" && (req.Header.Get("Content-Encoding") != "" || req.ContentLength != -1) {
+				t.Errorf("Content-Encoding %q and length %d left", req.Header.Get("Content-Encoding"), req.ContentLength)
+			}
+		})
+	}
+}
+
+func decode(t *testing.T, coding string, data []byte) []byte {
+	t.Helper()
+	var r io.Reader
+	switch coding {
+	case Gzip:
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	case Brotli:
+		r = brotli.NewReader(bytes.NewReader(data))
+	case Zstd:
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return out
+}
+
+func TestMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"name":"acme"}`, 10)
+	small := `{"name":"acme"}`
+
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		handler        gin.HandlerFunc
+		// encoding is the Content-Encoding of the response, set by the middleware when compressed,
+		// etag its ETag and body the body once decompressed
+		encoding   string
+		compressed bool
+		etag       string
+		body       string
+	}{
+		{
+			name:           "large json",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Header("ETag", `"abc"`)
+				c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(large))
+			},
+			encoding:   Gzip,
+			compressed: true,
+			etag:       `"abc-gzip"`,
+			body:       large,
+		},
+		{
+			name:           "preferred coding",
+			acceptEncoding: "gzip, br, zstd",
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusOK, "text/plain", []byte(large))
+			},
+			encoding:   Brotli,
+			compressed: true,
+			body:       large,
+		},
+		{
+			name:           "written in small pieces",
+			acceptEncoding: "zstd",
+			handler: func(c *gin.Context) {
+				c.Header("Content-Type", "application/json")
+				for i := 0; i < 10; i++ {
+					c.Writer.WriteString(small)
+				}
+			},
+			encoding:   Zstd,
+			compressed: true,
+			body:       large,
+		},
+		{
+			name:           "below MinSize",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Header("ETag", `"abc"`)
+				c.Data(http.StatusOK, "application/json", []byte(small))
+			},
+			etag: `"abc"`,
+			body: small,
+		},
+		{
+			name:           "not compressible",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusOK, "image/png", []byte(large))
+			},
+			body: large,
+		},
+		{
+			name:           "not accepted",
+			acceptEncoding: "deflate",
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusOK, "application/json", []byte(large))
+			},
+			body: large,
+		},
+		{
+			name:           "already encoded",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Header("Content-Encoding", "br")
+				c.Data(http.StatusOK, "application/json", []byte(large))
+			},
+			encoding: Brotli,
+			body:     large,
+		},
+		{
+			name:           "partial content",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusPartialContent, "application/json", []byte(large))
+			},
+			body: large,
+		},
+		{
+			name:           "not modified",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Header("ETag", `"abc"`)
+				c.Status(http.StatusNotModified)
+				c.Writer.WriteHeaderNow()
+			},
+			etag: `"abc"`,
+		},
+		{
+			name:           "head",
+			method:         http.MethodHead,
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			engine.Use(newCompressor(t).Middleware())
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			engine.Handle(method, "/", tt.handler)
+
+			req := httptest.NewRequest(method, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("Content-Encoding %q, want %q", got, tt.encoding)
+			}
+			if got := w.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("ETag %q, want %q", got, tt.etag)
+			}
+			if got := w.Header().Values("Vary"); len(got) != 1 || got[0] != "Accept-Encoding" {
+				t.Errorf("Vary %q, want Accept-Encoding", got)
+			}
+			body := w.Body.Bytes()
+			if tt.compressed {
+				body = decode(t, tt.encoding, body)
+			}
+			if string(body) != tt.body {
+				t.Errorf("body %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestCodingETag(t *testing.T) {
+	tests := []struct {
+		base, encoding, tag string
+	}{
+		{base: `"abc"`, encoding: Gzip, tag: `"abc-gzip"`},
+		{base: `"abc"`, encoding: Brotli, tag: `"abc-br"`},
+		{base: `"abc"`, encoding: Zstd, tag: `"abc-zstd"`},
+		{base: `W/"abc"`, encoding: Gzip, tag: `W/"abc-gzip"`},
+		// not a quoted tag, left as it is
+		{base: `abc`, encoding: Gzip, tag: `abc`},
+	}
+	for _, tt := range tests {
+		if got := codingETag(tt.base, tt.encoding); got != tt.tag {
+			t.Errorf("codingETag(%s, %s) = %s, want %s", tt.base, tt.encoding, got, tt.tag)
+		}
+		if got := ETagBase(tt.tag); got != tt.base {
+			t.Errorf("ETagBase(%s) = %s, want %s", tt.tag, got, tt.base)
+		}
+	}
+
+	if got := ETagBase(`"abc-deflate"`); got != `"abc-deflate"` {
+		t.Errorf("ETagBase stripped an unknown coding: %s", got)
+	}
+}
@@ -18,7 +18,12 @@ const docTemplate = `{
     "paths": {
         "/config": {
             "get": {
-                "description": "shows config of the project only on the development phase",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "shows effective config of the project with secrets masked, admin only",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,15 +35,6 @@ const docTemplate = `{
                 ],
                 "summary": "gets project config",
                 "operationId": "get-config",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "name",
-                        "name": "name",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
                 "responses": {
                     "200": {
                         "description": "desc",
@@ -51,15 +47,33 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/config.Config"
+                                            "$ref": "#/definitions/models.ConfigResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ResponseModel"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "allOf": [
                                 {
@@ -97,38 +111,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/attribute": {
-            "get": {
-                "description": "GetAll attribute",
+        "/rpc/{service}/{method}": {
+            "post": {
+                "description": "converts the body to the request message of any allowed unary method, discovered with server reflection or a descriptor set, and returns the response as protojson or in the format of the Accept header",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/x-protobuf",
+                    "application/msgpack",
+                    "application/yaml"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/x-protobuf",
+                    "application/msgpack",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "attribute"
+                    "rpc"
                 ],
-                "summary": "GetAll attribute",
-                "operationId": "GetAll-attribute",
+                "summary": "calls a backend gRPC method",
+                "operationId": "call-rpc",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "search",
-                        "name": "search",
-                        "in": "query"
+                        "description": "full service name, e.g. company_service.CompanyService",
+                        "name": "service",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "string",
+                        "description": "method name, e.g. GetById",
+                        "name": "method",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "offset",
-                        "name": "offset",
-                        "in": "query"
+                        "description": "request message",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
                     }
                 ],
                 "responses": {
@@ -143,7 +167,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/position_service.GetAllAttributeResponse"
+                                            "type": "object"
                                         }
                                     }
                                 }
@@ -168,8 +192,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "allOf": [
                                 {
@@ -185,54 +209,9 @@ const docTemplate = `{
                                 }
                             ]
                         }
-                    }
-                }
-            },
-            "put": {
-                "description": "update attribute",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "attribute"
-                ],
-                "summary": "update attribute",
-                "operationId": "update-attribute",
-                "parameters": [
-                    {
-                        "description": "attribute",
-                        "name": "attribute",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/position_service.UpdateAttributeRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.UpdateAttributeResponse"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "allOf": [
                                 {
@@ -249,8 +228,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Server Error",
+                    "406": {
+                        "description": "Not Acceptable",
                         "schema": {
                             "allOf": [
                                 {
@@ -266,36 +245,9 @@ const docTemplate = `{
                                 }
                             ]
                         }
-                    }
-                }
-            },
-            "post": {
-                "description": "create attribute",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "attribute"
-                ],
-                "summary": "create attribute",
-                "operationId": "create-attribute",
-                "parameters": [
-                    {
-                        "description": "attribute",
-                        "name": "attribute",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/position_service.CreateAttributeRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
                             "allOf": [
                                 {
@@ -304,16 +256,16 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.Attribute"
+                                        "error": {
+                                            "type": "string"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "415": {
+                        "description": "Unsupported Media Type",
                         "schema": {
                             "allOf": [
                                 {
@@ -347,54 +299,9 @@ const docTemplate = `{
                                 }
                             ]
                         }
-                    }
-                }
-            },
-            "delete": {
-                "description": "delete attribute",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "attribute"
-                ],
-                "summary": "delete attribute",
-                "operationId": "delete-attribute",
-                "parameters": [
-                    {
-                        "description": "attribute",
-                        "name": "attribute",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/position_service.DeleteAttributeRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.DeleteAttributeResponse"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "502": {
+                        "description": "Bad Gateway",
                         "schema": {
                             "allOf": [
                                 {
@@ -411,8 +318,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Server Error",
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
                             "allOf": [
                                 {
@@ -432,31 +339,38 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/attribute/:id": {
-            "get": {
-                "description": "GetID attribute",
+        "/v1/batch": {
+            "post": {
+                "description": "serves every request of the body with the routes of the gateway, without network hops, and returns their responses in the order sent. Requests run concurrently, BATCH_CONCURRENCY at a time, and carry the Authorization and Cache-Control headers of the batch. A path without version prefix is served in the version of the batch. Path, header values and body strings may reference the JSON response body of an earlier request by its id, e.g. /v2/company/${company.data.id}; the request then waits for it and is answered 424 when it failed.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/msgpack",
+                    "application/yaml"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/msgpack",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "attribute"
+                    "batch"
                 ],
-                "summary": "GetID attribute",
-                "operationId": "GetID-attribute",
+                "summary": "sends several requests at once",
+                "operationId": "batch",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "id",
-                        "name": "id",
-                        "in": "query"
+                        "description": "requests",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MultiRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "desc",
+                    "207": {
+                        "description": "Multi-Status",
                         "schema": {
                             "allOf": [
                                 {
@@ -466,7 +380,10 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/position_service.GetByIdAttributeResponse"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.SubResponse"
+                                            }
                                         }
                                     }
                                 }
@@ -491,8 +408,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Server Error",
+                    "406": {
+                        "description": "Not Acceptable",
                         "schema": {
                             "allOf": [
                                 {
@@ -508,47 +425,9 @@ const docTemplate = `{
                                 }
                             ]
                         }
-                    }
-                }
-            }
-        },
-        "/v1/company": {
-            "get": {
-                "description": "get company",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "company"
-                ],
-                "summary": "get company",
-                "operationId": "get-company",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "search",
-                        "name": "search",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query"
                     },
-                    {
-                        "type": "integer",
-                        "description": "offset",
-                        "name": "offset",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
                             "allOf": [
                                 {
@@ -557,16 +436,16 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/company_service.GetAllCompanyResponse"
+                                        "error": {
+                                            "type": "string"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "415": {
+                        "description": "Unsupported Media Type",
                         "schema": {
                             "allOf": [
                                 {
@@ -602,9 +481,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "update company",
+            }
+        },
+        "/v1/ping": {
+            "get": {
+                "description": "this returns \"pong\" messsage to show service is working",
                 "consumes": [
                     "application/json"
                 ],
@@ -612,21 +493,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "company"
-                ],
-                "summary": "update company",
-                "operationId": "update-company",
-                "parameters": [
-                    {
-                        "description": "company",
-                        "name": "company",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/company_service.UpdateCompanyRequest"
-                        }
-                    }
+                    "config"
                 ],
+                "summary": "returns \"pong\" message",
+                "operationId": "ping",
                 "responses": {
                     "200": {
                         "description": "desc",
@@ -639,24 +509,6 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/company_service.Company"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
                                             "type": "string"
                                         }
                                     }
@@ -665,1132 +517,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "create company",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "company"
-                ],
-                "summary": "create company",
-                "operationId": "create-company",
-                "parameters": [
-                    {
-                        "description": "company",
-                        "name": "company",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/company_service.CreateCompanyRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/company_service.Company"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            },
-            "delete": {
-                "description": "delete company",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "company"
-                ],
-                "summary": "delete company",
-                "operationId": "delete-company",
-                "parameters": [
-                    {
-                        "description": "company",
-                        "name": "company",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/company_service.DeleteCompanyRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/company_service.DeleteCompanyResponse"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            }
-        },
-        "/v1/company/:id": {
-            "get": {
-                "description": "GetID company",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "company"
-                ],
-                "summary": "GetID company",
-                "operationId": "GetID-company",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "id",
-                        "name": "id",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/company_service.GetByIdCompanyResponse"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            }
-        },
-        "/v1/ping": {
-            "get": {
-                "description": "this returns \"pong\" messsage to show service is working",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "config"
-                ],
-                "summary": "returns \"pong\" message",
-                "operationId": "ping",
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            }
-        },
-        "/v1/position": {
-            "get": {
-                "description": "get position",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "position"
-                ],
-                "summary": "get position",
-                "operationId": "get-position",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "search",
-                        "name": "search",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "offset",
-                        "name": "offset",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.GetAllPositionResponse"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            },
-            "put": {
-                "description": "update position",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "position"
-                ],
-                "summary": "update position",
-                "operationId": "update-position",
-                "parameters": [
-                    {
-                        "description": "position",
-                        "name": "position",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/position_service.UpdatePositionRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.PositionId"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "create position",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "position"
-                ],
-                "summary": "create position",
-                "operationId": "create-position",
-                "parameters": [
-                    {
-                        "description": "position",
-                        "name": "position",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/position_service.CreatePositionRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.PositionId"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            },
-            "delete": {
-                "description": "delete position",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "position"
-                ],
-                "summary": "delete position",
-                "operationId": "delete-position",
-                "parameters": [
-                    {
-                        "description": "position",
-                        "name": "position",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/position_service.PositionId"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.PositionId"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            }
-        },
-        "/v1/position/:id": {
-            "get": {
-                "description": "GetID position",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "position"
-                ],
-                "summary": "GetID position",
-                "operationId": "GetID-position",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "name": "id",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.Position"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            }
-        },
-        "/v1/profession": {
-            "get": {
-                "description": "get profession",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "profession"
-                ],
-                "summary": "get profession",
-                "operationId": "get-profession",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "search",
-                        "name": "search",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "offset",
-                        "name": "offset",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.GetAllProfessionResponse"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            },
-            "put": {
-                "description": "update profession",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "profession"
-                ],
-                "summary": "update profession",
-                "operationId": "update-profession",
-                "parameters": [
-                    {
-                        "description": "profession",
-                        "name": "profession",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/position_service.UpdateProfessionRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.UpdateProfessionResponse"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "create profession",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "profession"
-                ],
-                "summary": "create profession",
-                "operationId": "create-profession",
-                "parameters": [
-                    {
-                        "description": "profession",
-                        "name": "profession",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/position_service.CreateProfessionRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.Profession"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            },
-            "delete": {
-                "description": "delete profession",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "profession"
-                ],
-                "summary": "delete profession",
-                "operationId": "delete-profession",
-                "parameters": [
-                    {
-                        "description": "profession",
-                        "name": "profession",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/position_service.DeleteProfessionRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.DeleteProfessionResponse"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            }
-        },
-        "/v1/profession/:id": {
-            "get": {
-                "description": "GetID profession",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "profession"
-                ],
-                "summary": "GetID profession",
-                "operationId": "GetID-profession",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "id",
-                        "name": "id",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/position_service.GetByIdProfessionResponse"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ResponseModel"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Server Error",
+                        "description": "Internal Server Error",
                         "schema": {
                             "allOf": [
                                 {
@@ -1812,415 +539,132 @@ const docTemplate = `{
         }
     },
     "definitions": {
-        "company_service.Company": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "company_service.CreateCompanyRequest": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "company_service.DeleteCompanyRequest": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                }
-            }
-        },
-        "company_service.DeleteCompanyResponse": {
+        "config.Value": {
             "type": "object",
             "properties": {
-                "id": {
+                "key": {
                     "type": "string"
-                }
-            }
-        },
-        "company_service.GetAllCompanyResponse": {
-            "type": "object",
-            "properties": {
-                "Companys": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/company_service.Company"
-                    }
                 },
-                "count": {
-                    "type": "integer"
-                }
-            }
-        },
-        "company_service.GetByIdCompanyResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
+                "secret": {
+                    "type": "boolean"
                 },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "company_service.UpdateCompanyRequest": {
-            "type": "object",
-            "properties": {
-                "id": {
+                "source": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string"
-                }
+                "value": {}
             }
         },
-        "config.Config": {
+        "models.ConfigResponse": {
             "type": "object",
             "properties": {
-                "companyServiceHost": {
+                "build_time": {
                     "type": "string"
                 },
-                "companyServicePort": {
-                    "type": "integer"
-                },
                 "environment": {
-                    "description": "develop, staging, production",
-                    "type": "string"
-                },
-                "httpPort": {
-                    "type": "string"
-                },
-                "logLevel": {
-                    "type": "string"
-                },
-                "positionServiceHost": {
-                    "type": "string"
-                },
-                "positionServicePort": {
-                    "type": "integer"
-                }
-            }
-        },
-        "models.ResponseModel": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer"
-                },
-                "data": {},
-                "error": {},
-                "message": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.Attribute": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "type": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.CreateAttributeRequest": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                },
-                "type": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.CreatePositionRequest": {
-            "type": "object",
-            "properties": {
-                "company_id": {
                     "type": "string"
                 },
-                "name": {
+                "git_commit": {
                     "type": "string"
                 },
-                "position_attributes": {
+                "values": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/position_service.PositionAttributes"
+                        "$ref": "#/definitions/config.Value"
                     }
                 },
-                "profession_id": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.CreateProfessionRequest": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.DeleteAttributeRequest": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.DeleteAttributeResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.DeleteProfessionRequest": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.DeleteProfessionResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
+                "version": {
                     "type": "string"
                 }
             }
         },
-        "position_service.GetAllAttributeResponse": {
+        "models.MultiRequest": {
             "type": "object",
             "properties": {
-                "Attributes": {
+                "requests": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/position_service.Attribute"
+                        "$ref": "#/definitions/models.SubRequest"
                     }
-                },
-                "count": {
-                    "type": "integer"
                 }
             }
         },
-        "position_service.GetAllPositionResponse": {
+        "models.PageMeta": {
             "type": "object",
             "properties": {
-                "count": {
-                    "type": "integer"
+                "has_more": {
+                    "type": "boolean"
                 },
-                "positions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/position_service.Position"
-                    }
-                }
-            }
-        },
-        "position_service.GetAllProfessionResponse": {
-            "type": "object",
-            "properties": {
-                "count": {
+                "limit": {
                     "type": "integer"
                 },
-                "professions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/position_service.Profession"
-                    }
-                }
-            }
-        },
-        "position_service.GetByIdAttributeResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "name": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "type": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.GetByIdProfessionResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
+                "prev_cursor": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "position_service.GetPositionAttributes": {
+        "models.ResponseModel": {
             "type": "object",
             "properties": {
-                "attribute_id": {
-                    "type": "string"
-                },
-                "attribute_name": {
-                    "type": "string"
-                },
-                "attribute_type": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
+                "code": {
+                    "type": "integer"
                 },
-                "position_id": {
+                "data": {},
+                "error": {},
+                "message": {
                     "type": "string"
                 },
-                "value": {
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/models.PageMeta"
                 }
             }
         },
-        "position_service.Position": {
+        "models.SubRequest": {
             "type": "object",
             "properties": {
-                "company_id": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
+                "body": {
+                    "type": "object"
                 },
-                "name": {
-                    "type": "string"
-                },
-                "position_attributes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/position_service.GetPositionAttributes"
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
                     }
                 },
-                "profession_id": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.PositionAttributes": {
-            "type": "object",
-            "properties": {
-                "attribute_id": {
-                    "type": "string"
-                },
-                "value": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.PositionId": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.Profession": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.UpdateAttributeRequest": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "type": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.UpdateAttributeResponse": {
-            "type": "object",
-            "properties": {
                 "id": {
                     "type": "string"
                 },
-                "name": {
+                "method": {
                     "type": "string"
                 },
-                "type": {
+                "path": {
                     "type": "string"
                 }
             }
         },
-        "position_service.UpdatePositionRequest": {
+        "models.SubResponse": {
             "type": "object",
             "properties": {
-                "company_id": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
+                "body": {},
+                "code": {
+                    "type": "integer"
                 },
-                "position_attributes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/position_service.PositionAttributes"
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
                     }
                 },
-                "profession_id": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.UpdateProfessionRequest": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "position_service.UpdateProfessionResponse": {
-            "type": "object",
-            "properties": {
                 "id": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string"
+                "index": {
+                    "type": "integer"
                 }
             }
         }
@@ -0,0 +1,326 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/pkg/logger"
+	"github.com/xfirdavs/api_gateway/pkg/util"
+	"github.com/xfirdavs/api_gateway/services"
+	"google.golang.org/protobuf/proto"
+)
+
+// batchItem is an operation of a batch request, its bound request and its result
+type batchItem struct {
+	op     models.BatchOperation
+	route  *routes.Route
+	req    proto.Message
+	result models.BatchResult
+
+	// current is the resource an update or delete changes, read before the call
+	current proto.Message
+	// done is set once the backend call succeeded, the change is undone when an all_or_nothing batch fails
+	done bool
+}
+
+func (item *batchItem) fail(failure *models.ResponseModel) {
+	item.result.Code, item.result.Message, item.result.Error, item.result.Data = failure.Code, failure.Message, failure.Error, nil
+}
+
+func (item *batchItem) failed() bool {
+	return item.result.Code >= http.StatusBadRequest
+}
+
+// Batch returns the handler of the batch route of a resource: it runs the creates, updates and
+// deletes of the body, BATCH_CONCURRENCY at a time, and answers 207 with the result every
+// operation would have got from its route, in the order sent
+func (h *handlerV1) Batch(batch *routes.Batch) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.ContentType() == routes.MIMEProtobuf {
+			h.handleErrorResponse(c, http.StatusUnsupportedMediaType, "unsupported media type",
+				"a batch is sent as JSON, MessagePack or YAML")
+			return
+		}
+
+		body, err := routes.ReadBody(c.Request, h.cfg.MaxBodySize)
+		if err != nil {
+			h.handleBindError(c, err)
+			return
+		}
+		var req models.BatchRequest
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			h.handleBindError(c, &routes.DecodeError{Reason: "invalid batch: " + err.Error()})
+			return
+		}
+
+		if req.Mode == "" {
+			req.Mode = routes.BatchBestEffort
+		}
+		switch {
+		case req.Mode != routes.BatchBestEffort && req.Mode != routes.BatchAllOrNothing:
+			h.handleErrorResponse(c, http.StatusBadRequest, "invalid batch",
+				"mode must be "+routes.BatchBestEffort+" or "+routes.BatchAllOrNothing)
+			return
+		case len(req.Operations) == 0:
+			h.handleErrorResponse(c, http.StatusBadRequest, "invalid batch", "operations is empty")
+			return
+		case len(req.Operations) > h.cfg.BatchMaxItems:
+			h.handleErrorResponse(c, http.StatusBadRequest, "invalid batch",
+				fmt.Sprintf("a batch has at most %d operations", h.cfg.BatchMaxItems))
+			return
+		}
+
+		items := make([]*batchItem, 0, len(req.Operations))
+		for i, op := range req.Operations {
+			items = append(items, &batchItem{op: op, result: models.BatchResult{Index: i, Action: op.Action, ID: op.ID}})
+		}
+
+		ctx, message := c.Request.Context(), "ok"
+		if req.Mode == routes.BatchAllOrNothing {
+			message = h.runAllOrNothing(ctx, batch, items)
+		} else {
+			h.parallel(items, func(item *batchItem) bool {
+				if h.prepare(ctx, batch, item) {
+					h.execute(ctx, item)
+				}
+				return true
+			})
+		}
+
+		results, failed := make([]models.BatchResult, 0, len(items)), 0
+		for _, item := range items {
+			results = append(results, item.result)
+			if item.failed() {
+				failed++
+			}
+		}
+
+		h.log.Info(message, logger.String("batch", batch.Path), logger.String("mode", req.Mode),
+			logger.Int("operations", len(items)), logger.Int("failed", failed))
+		h.render(c, http.StatusMultiStatus, models.ResponseModel{
+			Code:    http.StatusMultiStatus,
+			Message: message,
+			Data:    results,
+		})
+	}
+}
+
+// runAllOrNothing runs the operations when every one is valid: the creates and updates
+// first, then the deletes, which can not be undone. When one fails the creates and
+// updates done are undone. It returns the message of the batch response.
+func (h *handlerV1) runAllOrNothing(ctx context.Context, batch *routes.Batch, items []*batchItem) string {
+	h.parallel(items, func(item *batchItem) bool {
+		h.prepare(ctx, batch, item)
+		return true
+	})
+	if first := firstFailed(items); first != nil {
+		for _, item := range items {
+			if !item.failed() {
+				item.fail(&models.ResponseModel{Code: http.StatusFailedDependency, Message: "not run",
+					Error: fmt.Sprintf("operation %d is invalid", first.result.Index)})
+			}
+		}
+		return "batch not run, an operation is invalid"
+	}
+
+	var writes, deletes []*batchItem
+	for _, item := range items {
+		if item.op.Action == routes.ActionDelete {
+			deletes = append(deletes, item)
+		} else {
+			writes = append(writes, item)
+		}
+	}
+	execute := func(item *batchItem) bool {
+		return h.execute(ctx, item)
+	}
+	if h.parallel(writes, execute) && h.parallel(deletes, execute) {
+		return "ok"
+	}
+
+	// the changes are undone even when the client went away
+	first, undoCtx := firstFailed(items), context.WithoutCancel(ctx)
+	reason := fmt.Sprintf("operation %d failed", first.result.Index)
+	h.parallel(items, func(item *batchItem) bool {
+		switch {
+		case item.failed():
+		case !item.done:
+			item.fail(&models.ResponseModel{Code: http.StatusFailedDependency, Message: "not run", Error: reason})
+		case item.op.Action == routes.ActionDelete:
+			item.result.Message, item.result.Error = "not undone", "deletes can not be undone"
+		default:
+			h.undo(undoCtx, batch.Resource, item, reason)
+		}
+		return true
+	})
+
+	return "batch failed, its creates and updates were undone"
+}
+
+// prepare binds the request of an operation and makes the checks of its route,
+// recording the failure in the result of item
+func (h *handlerV1) prepare(ctx context.Context, batch *routes.Batch, item *batchItem) bool {
+	op, res := item.op, batch.Resource
+
+	route, ok := batch.Routes[op.Action]
+	if !ok {
+		item.fail(&models.ResponseModel{Code: http.StatusBadRequest, Message: "invalid action",
+			Error: "action must be " + routes.ActionCreate + ", " + routes.ActionUpdate + " or " + routes.ActionDelete})
+		return false
+	}
+	item.route = route
+	if op.Action != routes.ActionCreate && !util.IsValidUUID(op.ID) {
+		item.fail(&models.ResponseModel{Code: http.StatusBadRequest, Message: "invalid id", Error: op.ID + " is not a valid UUID"})
+		return false
+	}
+
+	msg := route.Input.New()
+	if body := bytes.TrimSpace(op.Body); route.Body != "" && len(body) > 0 && !bytes.Equal(body, []byte("null")) {
+		if int64(len(body)) > route.MaxBodySize {
+			item.fail(bindFailure(routes.ErrBodyTooLarge))
+			return false
+		}
+		if err := routes.DecodeJSON(body, msg.Interface(), route.DiscardUnknown); err != nil {
+			item.fail(bindFailure(err))
+			return false
+		}
+	}
+	if op.Action != routes.ActionCreate {
+		if err := routes.SetField(msg, res.IDField, []string{op.ID}); err != nil {
+			item.fail(bindFailure(err))
+			return false
+		}
+	}
+	item.req = msg.Interface()
+
+	if route.Validation != nil {
+		if err := route.Validation.Validate(item.req, nil); err != nil {
+			item.fail(validationFailure(err))
+			return false
+		}
+	}
+	if op.Action != routes.ActionCreate {
+		// the resource is about to change, a cached version must not stand in for it
+		current, err := h.getResource(services.Fresh(ctx), res, op.ID)
+		if err != nil {
+			item.fail(resourceFailure(route, op.ID, err))
+			return false
+		}
+		item.current = current
+		if op.Action == routes.ActionUpdate {
+			if failure := immutableFailure(res, current, item.req); failure != nil {
+				item.fail(failure)
+				return false
+			}
+		}
+	}
+	if failure := h.writeFailure(ctx, route, item.req); failure != nil {
+		item.fail(failure)
+		return false
+	}
+
+	return true
+}
+
+// execute calls the method of a prepared operation
+func (h *handlerV1) execute(ctx context.Context, item *batchItem) bool {
+	route := item.route
+	resp, err := h.invoke(ctx, route.RPC, item.req)
+	if err != nil {
+		item.fail(resourceFailure(route, item.op.ID, err))
+		return false
+	}
+	item.done = true
+
+	switch item.op.Action {
+	case routes.ActionCreate:
+		item.result.ID = routes.GetField(resp.ProtoReflect(), route.Resource.IDField).String()
+	case routes.ActionDelete:
+		item.result.Code, item.result.Message = http.StatusNoContent, "ok"
+		return true
+	}
+
+	data, err := h.encoder.EncodeData(route.Response(resp), nil)
+	if err != nil {
+		item.fail(&models.ResponseModel{Code: http.StatusInternalServerError, Message: "error while encoding response", Error: err.Error()})
+		return false
+	}
+	item.result.Code, item.result.Message, item.result.Data = route.Status, "ok", data
+
+	return true
+}
+
+// undo deletes the resource a create made or puts back the one an update changed
+func (h *handlerV1) undo(ctx context.Context, res *routes.Resource, item *batchItem, reason string) {
+	var err error
+	if item.op.Action == routes.ActionCreate {
+		req := routes.NewMessage(res.Delete.Input())
+		if err = routes.SetField(req.ProtoReflect(), res.IDField, []string{item.result.ID}); err == nil {
+			_, err = h.invoke(ctx, res.Delete, req)
+		}
+	} else {
+		var req proto.Message
+		if req, err = res.RestoreRequest(item.current); err == nil {
+			_, err = h.invoke(ctx, res.Update, req)
+		}
+	}
+
+	if err != nil {
+		h.log.Error("error while undoing batch operation", logger.String("resource", res.Name),
+			logger.String("id", item.result.ID), logger.Error(err))
+		item.result.Message, item.result.Error = "not undone", "error while undoing: "+err.Error()
+		return
+	}
+	item.fail(&models.ResponseModel{Code: http.StatusFailedDependency, Message: "undone", Error: reason})
+}
+
+// parallel calls fn for the items, BATCH_CONCURRENCY at a time, and stops starting new
+// calls once one returned false. It reports whether every call returned true.
+func (h *handlerV1) parallel(items []*batchItem, fn func(*batchItem) bool) bool {
+	var (
+		wg      sync.WaitGroup
+		stopped atomic.Bool
+	)
+	sem := make(chan struct{}, h.cfg.BatchConcurrency)
+	for _, item := range items {
+		sem <- struct{}{}
+		if stopped.Load() {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(item *batchItem) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if !fn(item) {
+				stopped.Store(true)
+			}
+		}(item)
+	}
+	wg.Wait()
+
+	return !stopped.Load()
+}
+
+func firstFailed(items []*batchItem) *batchItem {
+	for _, item := range items {
+		if item.failed() {
+			return item
+		}
+	}
+
+	return nil
+}
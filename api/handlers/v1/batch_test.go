@@ -0,0 +1,191 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/config"
+	"github.com/xfirdavs/api_gateway/genproto/company_service"
+	"github.com/xfirdavs/api_gateway/genproto/position_service"
+	"github.com/xfirdavs/api_gateway/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// companyBackend is an in-memory CompanyService, creates of the name "fail" fail and
+// deletes fail when failDeletes is set
+type companyBackend struct {
+	mu          sync.Mutex
+	names       map[string]string
+	n           int
+	failDeletes bool
+}
+
+func (b *companyBackend) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var resp proto.Message
+	switch req := args.(type) {
+	case *company_service.CreateCompanyRequest:
+		if req.Name == "fail" {
+			return status.Error(codes.Unavailable, "backend down")
+		}
+		b.n++
+		id := fmt.Sprintf("00000000-0000-4000-8000-%012d", b.n)
+		b.names[id] = req.Name
+		resp = &company_service.Company{Id: id, Name: req.Name}
+	case *company_service.GetByIdCompanyRequest:
+		name, ok := b.names[req.Id]
+		if !ok {
+			return status.Error(codes.NotFound, "company not found")
+		}
+		resp = &company_service.GetByIdCompanyResponse{Id: req.Id, Name: name}
+	case *company_service.UpdateCompanyRequest:
+		if _, ok := b.names[req.Id]; !ok {
+			return status.Error(codes.NotFound, "company not found")
+		}
+		b.names[req.Id] = req.Name
+		resp = &company_service.UpdateCompanyResponse{Id: req.Id, Name: req.Name}
+	case *company_service.DeleteCompanyRequest:
+		if b.failDeletes {
+			return status.Error(codes.Unavailable, "backend down")
+		}
+		delete(b.names, req.Id)
+		resp = &company_service.DeleteCompanyResponse{Id: req.Id}
+	default:
+		return status.Errorf(codes.Unimplemented, "%s", method)
+	}
+	proto.Merge(reply.(proto.Message), resp)
+
+	return nil
+}
+
+func (b *companyBackend) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Error(codes.Unimplemented, "streams are not supported")
+}
+
+func (b *companyBackend) ProfessionService() position_service.ProfessionServiceClient { return nil }
+func (b *companyBackend) AttributeService() position_service.AttributeServiceClient   { return nil }
+func (b *companyBackend) PositionService() position_service.PositionServiceClient     { return nil }
+func (b *companyBackend) CompanyService() company_service.CompanyServiceClient {
+	return company_service.NewCompanyServiceClient(b)
+}
+func (b *companyBackend) Conn(string) (grpc.ClientConnInterface, error) { return b, nil }
+func (b *companyBackend) Conns() []grpc.ClientConnInterface             { return []grpc.ClientConnInterface{b} }
+
+func companyBatch(t *testing.T, version string) *routes.Batch {
+	t.Helper()
+	api, err := routes.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range api.Batches {
+		if b.Path == "/"+version+"/company/batch" {
+			return b
+		}
+	}
+	t.Fatalf("no batch route for company in %s", version)
+
+	return nil
+}
+
+func runBatch(h *handlerV1, batch *routes.Batch, ops ...models.BatchOperation) []*batchItem {
+	items := make([]*batchItem, 0, len(ops))
+	for i, op := range ops {
+		items = append(items, &batchItem{op: op, result: models.BatchResult{Index: i, Action: op.Action, ID: op.ID}})
+	}
+	h.runAllOrNothing(context.Background(), batch, items)
+
+	return items
+}
+
+func createOp(name string) models.BatchOperation {
+	return models.BatchOperation{Action: routes.ActionCreate, Body: json.RawMessage(`{"name":"` + name + `"}`)}
+}
+
+func TestBatchAllOrNothingUndo(t *testing.T) {
+	const existing = "00000000-0000-4000-8000-000000000999"
+
+	tests := []struct {
+		name        string
+		version     string
+		failDeletes bool
+		ops         []models.BatchOperation
+		// codes and messages of the results, names the companies left in the backend
+		codes    []int
+		messages []string
+		names    map[string]string
+	}{
+		{
+			name:     "create undone",
+			version:  "v2",
+			ops:      []models.BatchOperation{createOp("a"), createOp("fail")},
+			codes:    []int{http.StatusFailedDependency, http.StatusServiceUnavailable},
+			messages: []string{"undone", "error while calling company_service.CompanyService.Create"},
+			names:    map[string]string{existing: "old"},
+		},
+		{
+			name:    "update restored",
+			version: "v2",
+			ops: []models.BatchOperation{
+				{Action: routes.ActionUpdate, ID: existing, Body: json.RawMessage(`{"name":"new"}`)},
+				createOp("fail"),
+			},
+			codes:    []int{http.StatusFailedDependency, http.StatusServiceUnavailable},
+			messages: []string{"undone", "error while calling company_service.CompanyService.Create"},
+			names:    map[string]string{existing: "old"},
+		},
+		{
+			name:        "failed undo",
+			version:     "v2",
+			failDeletes: true,
+			ops:         []models.BatchOperation{createOp("a"), createOp("fail")},
+			codes:       []int{http.StatusCreated, http.StatusServiceUnavailable},
+			messages:    []string{"not undone", "error while calling company_service.CompanyService.Create"},
+			names:       map[string]string{existing: "old", "00000000-0000-4000-8000-000000000001": "a"},
+		},
+		{
+			name:     "v1 create undone",
+			version:  "v1",
+			ops:      []models.BatchOperation{createOp("a"), createOp("fail")},
+			codes:    []int{http.StatusFailedDependency, http.StatusServiceUnavailable},
+			messages: []string{"undone", "error while calling company_service.CompanyService.Create"},
+			names:    map[string]string{existing: "old"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &companyBackend{names: map[string]string{existing: "old"}, failDeletes: tt.failDeletes}
+			h := &handlerV1{
+				log:      logger.New("error", "test"),
+				cfg:      config.Config{BatchConcurrency: 1},
+				services: backend,
+			}
+
+			items := runBatch(h, companyBatch(t, tt.version), tt.ops...)
+			for i, item := range items {
+				if item.result.Code != tt.codes[i] || item.result.Message != tt.messages[i] {
+					t.Errorf("operation %d: got %d %q, want %d %q", i, item.result.Code, item.result.Message, tt.codes[i], tt.messages[i])
+				}
+			}
+			if len(backend.names) != len(tt.names) {
+				t.Errorf("backend has %v, want %v", backend.names, tt.names)
+			}
+			for id, name := range tt.names {
+				if backend.names[id] != name {
+					t.Errorf("company %s is %q, want %q", id, backend.names[id], name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/services"
+)
+
+// cacheStatusKey is the gin context key of the response cache lookups of the request
+const cacheStatusKey = "cache"
+
+// CacheControl passes the Cache-Control directives of the request to the cached backend calls:
+// no-cache fetches fresh responses, no-store bypasses the cache and max-age bounds the age
+// of the cached responses used
+func (h *handlerV1) CacheControl() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, status := services.WithCacheControl(c.Request.Context(), c.GetHeader("Cache-Control"))
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(cacheStatusKey, status)
+
+		c.Next()
+	}
+}
+
+// setCacheHeaders writes the X-Cache header, HIT when the cache answered every cached call of
+// the request and MISS otherwise, and the Age in seconds of the oldest cached response used
+func setCacheHeaders(c *gin.Context) {
+	status, ok := c.Value(cacheStatusKey).(*services.CacheStatus)
+	if !ok {
+		return
+	}
+
+	result, age := status.Result()
+	if result == "" {
+		return
+	}
+	c.Header("X-Cache", result)
+	if result == services.CacheHit {
+		c.Header("Age", strconv.Itoa(int(age.Seconds())))
+	}
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/services"
+	"google.golang.org/protobuf/proto"
+)
+
+// notModified sets the ETag of a successful GET response and answers 304 without a body
+// when If-None-Match lists it
+func (h *handlerV1) notModified(c *gin.Context, code int, data interface{}, meta *models.PageMeta) bool {
+	if c.Request.Method != http.MethodGet || code < 200 || code >= 300 {
+		return false
+	}
+
+	tag, ok := responseETag(c, data, meta)
+	if !ok {
+		return false
+	}
+	c.Header(routes.HeaderETag, tag)
+
+	// the tag the client has, of the compressed representation it may have received
+	matched, ok := routes.MatchETag(c.GetHeader(routes.HeaderIfNoneMatch), tag, true)
+	if !ok {
+		return false
+	}
+	c.Header(routes.HeaderETag, matched)
+	setCacheHeaders(c)
+	c.Status(http.StatusNotModified)
+	c.Writer.WriteHeaderNow()
+
+	return true
+}
+
+// responseETag hashes the negotiated format and the data of a response, pruned to the sparse fieldset
+// of the request, with its expansions and page meta. The ETag of a resource read without fields and
+// expand is resourceETag.
+func responseETag(c *gin.Context, data interface{}, meta *models.PageMeta) (string, bool) {
+	hash := routes.NewETagHash(negotiated(c))
+
+	items, isList := data.([]interface{})
+	if !isList {
+		items = []interface{}{data}
+	}
+	for _, item := range items {
+		if msg, ok := item.(proto.Message); ok {
+			item = pruned(c, msg)
+		}
+		if err := hash.Value(item); err != nil {
+			return "", false
+		}
+	}
+
+	embeds, _ := c.Value(embedsKey).([]map[string]interface{})
+	for _, embed := range embeds {
+		names := make([]string, 0, len(embed))
+		for name := range embed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := hash.Value(name); err != nil {
+				return "", false
+			}
+			if err := hash.Value(embed[name]); err != nil {
+				return "", false
+			}
+		}
+	}
+
+	if meta != nil {
+		if err := hash.Value(meta); err != nil {
+			return "", false
+		}
+	}
+
+	return hash.Sum(), true
+}
+
+// resourceETag is the ETag of GET /{name}/{id} answering msg in mediaType
+func resourceETag(msg proto.Message, mediaType string) (string, error) {
+	hash := routes.NewETagHash(mediaType)
+	if err := hash.Message(msg); err != nil {
+		return "", err
+	}
+
+	return hash.Sum(), nil
+}
+
+// currentResource fetches the resource an update, patch or delete changes, answering 404 when it does
+// not exist and 412 when If-Match lists no ETag of its current version, in any format. The check is
+// best-effort: the backend takes no expected version, a change between the read and the write is
+// not detected.
+func (h *handlerV1) currentResource(c *gin.Context, route *routes.Route, id string) (proto.Message, bool) {
+	res := route.Modifies
+	ifMatch := c.GetHeader(routes.HeaderIfMatch)
+
+	// the resource is about to change, a cached version must not stand in for it
+	current, err := h.getResource(services.Fresh(c.Request.Context()), res, id)
+	if errors.Is(err, errResourceNotFound) {
+		h.handleErrorResponse(c, http.StatusNotFound, res.Name+" not found", id)
+		return nil, false
+	}
+	if err != nil {
+		h.handleErrorResponse(c, httpStatusFromError(err), "error while calling "+route.Selector, err.Error())
+		return nil, false
+	}
+	if ifMatch == "" {
+		return current, true
+	}
+
+	// the client may have read the resource in another format than the one it writes
+	for _, format := range routes.Formats {
+		tag, err := resourceETag(current, format)
+		if err != nil {
+			h.handleErrorResponse(c, http.StatusInternalServerError, "error while computing etag", err.Error())
+			return nil, false
+		}
+		if _, ok := routes.MatchETag(ifMatch, tag, false); ok {
+			return current, true
+		}
+	}
+
+	tag, _ := resourceETag(current, negotiated(c))
+	h.handleErrorResponse(c, http.StatusPreconditionFailed, "precondition failed",
+		res.Name+" "+id+" changed since it was read, its ETag is "+tag)
+
+	return nil, false
+}
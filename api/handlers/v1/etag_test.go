@@ -0,0 +1,150 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/config"
+	"github.com/xfirdavs/api_gateway/genproto/company_service"
+	"github.com/xfirdavs/api_gateway/pkg/logger"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNotModified(t *testing.T) {
+	company := &company_service.Company{Id: "1", Name: "acme"}
+	tag, err := resourceETag(company, binding.MIMEJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlTag, err := resourceETag(company, routes.MIMEYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := strings.TrimSuffix(tag, `"`)
+
+	h := &handlerV1{log: logger.New("error", "test"), cfg: config.Config{MaxBodySize: 1 << 20}}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	answer := func(c *gin.Context) {
+		h.handleSuccessResponse(c, http.StatusOK, "ok", company)
+	}
+	engine.GET("/company", h.Negotiate(nil), answer)
+	engine.POST("/company", h.Negotiate(nil), answer)
+
+	tests := []struct {
+		name        string
+		method      string
+		accept      string
+		ifNoneMatch string
+		// code of the response and its ETag
+		code int
+		etag string
+	}{
+		{name: "no condition", code: http.StatusOK, etag: tag},
+		{name: "unchanged", ifNoneMatch: tag, code: http.StatusNotModified, etag: tag},
+		{name: "listed", ifNoneMatch: `"x", ` + tag, code: http.StatusNotModified, etag: tag},
+		{name: "weak", ifNoneMatch: "W/" + tag, code: http.StatusNotModified, etag: "W/" + tag},
+		{name: "compressed", ifNoneMatch: base + `-gzip"`, code: http.StatusNotModified, etag: base + `-gzip"`},
+		{name: "any", ifNoneMatch: "*", code: http.StatusNotModified, etag: tag},
+		{name: "changed", ifNoneMatch: `"x"`, code: http.StatusOK, etag: tag},
+		{name: "other format", accept: routes.MIMEYAML, ifNoneMatch: tag, code: http.StatusOK, etag: yamlTag},
+		{name: "in the format", accept: routes.MIMEYAML, ifNoneMatch: yamlTag, code: http.StatusNotModified, etag: yamlTag},
+		{name: "not a read", method: http.MethodPost, ifNoneMatch: tag, code: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/company", nil)
+			req.Header.Set(routes.HeaderIfNoneMatch, tt.ifNoneMatch)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("code %d, want %d", w.Code, tt.code)
+			}
+			if got := w.Header().Get(routes.HeaderETag); got != tt.etag {
+				t.Errorf("ETag %s, want %s", got, tt.etag)
+			}
+			if tt.code == http.StatusNotModified && w.Body.Len() > 0 {
+				t.Errorf("304 with a body: %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestCurrentResourceIfMatch(t *testing.T) {
+	const id = "00000000-0000-4000-8000-000000000001"
+
+	api, err := routes.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var route *routes.Route
+	for _, r := range api.Routes {
+		if r.Method == http.MethodPut && r.Modifies != nil && r.Modifies.Name == "company" {
+			route = r
+		}
+	}
+	if route == nil {
+		t.Fatal("no update route of company")
+	}
+
+	current := &company_service.GetByIdCompanyResponse{Id: id, Name: "acme"}
+	tag, err := resourceETag(current, binding.MIMEJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlTag, err := resourceETag(current, routes.MIMEYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		ifMatch string
+		// code is the error response, 0 when the update may go on
+		code int
+	}{
+		{name: "no condition", id: id},
+		{name: "current", id: id, ifMatch: tag},
+		{name: "read in another format", id: id, ifMatch: yamlTag},
+		{name: "compressed", id: id, ifMatch: strings.TrimSuffix(tag, `"`) + `-br"`},
+		{name: "any", id: id, ifMatch: "*"},
+		{name: "changed", id: id, ifMatch: `"x"`, code: http.StatusPreconditionFailed},
+		{name: "weak", id: id, ifMatch: "W/" + tag, code: http.StatusPreconditionFailed},
+		{name: "missing", id: "00000000-0000-4000-8000-000000000002", ifMatch: tag, code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &handlerV1{
+				log:      logger.New("error", "test"),
+				services: &companyBackend{names: map[string]string{id: "acme"}},
+			}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/v2/company/"+tt.id, nil)
+			c.Request.Header.Set(routes.HeaderIfMatch, tt.ifMatch)
+
+			got, ok := h.currentResource(c, route, tt.id)
+			if ok != (tt.code == 0) {
+				t.Fatalf("currentResource ok %v, want %v: %s", ok, tt.code == 0, w.Body)
+			}
+			if ok && !proto.Equal(got, current) {
+				t.Errorf("current resource %v, want %v", got, current)
+			}
+			if !ok && w.Code != tt.code {
+				t.Errorf("code %d, want %d", w.Code, tt.code)
+			}
+		})
+	}
+}
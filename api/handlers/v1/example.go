@@ -1,8 +1,11 @@
 package v1
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/config"
 	"github.com/xfirdavs/api_gateway/pkg/logger"
 )
 
@@ -28,28 +31,23 @@ func (h *handlerV1) Ping(c *gin.Context) {
 // @ID get-config
 // @Router /config [GET]
 // @Summary gets project config
-// @Description shows config of the project only on the development phase
+// @Description shows effective config of the project with secrets masked, admin only
 // @Tags config
 // @Accept json
 // @Produce json
-// @Param name query string true "name"
-// @Success 200 {object} models.ResponseModel{data=config.Config} "desc"
-// @Response 400 {object} models.ResponseModel{error=string} "Bad Request"
-// @Response 400 {object} models.ResponseModel{error=string} "Bad Request"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.ResponseModel{data=models.ConfigResponse} "desc"
+// @Response 401 {object} models.ResponseModel{error=string} "Unauthorized"
+// @Response 403 {object} models.ResponseModel{error=string} "Forbidden"
 // @Failure 500 {object} models.ResponseModel{error=string} "Server Error"
 func (h *handlerV1) GetConfig(c *gin.Context) {
 	h.log.Info("get config", logger.String("environment", h.cfg.Environment))
-	switch h.cfg.Environment {
-	case "development":
-		h.handleSuccessResponse(c, 200, "ok", h.cfg)
-		return
-	case "staging":
-		h.handleSuccessResponse(c, 200, h.cfg.Environment, nil)
-		return
-	case "production":
-		h.handleSuccessResponse(c, 200, "private data", nil)
-		return
-	}
 
-	h.handleErrorResponse(c, 400, "wrong environment", h.cfg.Environment)
+	h.handleSuccessResponse(c, http.StatusOK, "ok", models.ConfigResponse{
+		Environment: h.cfg.Environment,
+		Version:     config.Version,
+		GitCommit:   config.GitCommit,
+		BuildTime:   config.BuildTime,
+		Values:      h.cfg.Effective(),
+	})
 }
@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/pkg/logger"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// expandKey and embedsKey are the gin context keys of the requested expansions and their results
+	expandKey = "expand"
+	embedsKey = "embeds"
+
+	// maxFetchCalls bounds the concurrent GetById calls of one request
+	maxFetchCalls = 8
+
+	// missingExpansionsKey lists the expansions of an item that could not be fetched
+	missingExpansionsKey = "missing_expansions"
+)
+
+// parseExpand reads the expand query parameter of the resource get and list routes before the call
+func (h *handlerV1) parseExpand(c *gin.Context, route *routes.Route) bool {
+	expr := c.Query(routes.ParamExpand)
+	if expr == "" || !route.HasParam(routes.ParamExpand) {
+		return true
+	}
+
+	expansions, err := route.Resource.ParseExpand(expr)
+	if err != nil {
+		h.handleErrorResponse(c, http.StatusBadRequest, "invalid expand", err.Error())
+		return false
+	}
+
+	c.Set(expandKey, expansions)
+	return true
+}
+
+// expand fetches the requested expansions of items, messages of the resource, concurrently, every referenced id once,
+// and keeps them for the response. An expansion that fails is embedded as null and
+// named in the item's missing_expansions instead of failing the request.
+// Binary protobuf responses have no room for expansions, they are not fetched.
+func (h *handlerV1) expand(c *gin.Context, items []interface{}) {
+	expansions, _ := c.Value(expandKey).([]*routes.Expansion)
+	if len(expansions) == 0 || negotiated(c) == routes.MIMEProtobuf {
+		return
+	}
+
+	refs := map[resourceRef]bool{}
+	for _, item := range items {
+		for _, e := range expansions {
+			if id := routes.GetField(item.(proto.Message).ProtoReflect(), e.Field).String(); id != "" {
+				refs[resourceRef{e.Target, id}] = true
+			}
+		}
+	}
+
+	results, errs := h.fetchResources(c.Request.Context(), refs)
+	for ref, err := range errs {
+		if !errors.Is(err, errResourceNotFound) {
+			h.log.Error("error while expanding "+ref.res.Name+" "+ref.id, logger.Error(err))
+		}
+	}
+
+	embeds := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		embed := map[string]interface{}{}
+		var missing []string
+		for _, e := range expansions {
+			embed[e.Name] = nil
+
+			id := routes.GetField(item.(proto.Message).ProtoReflect(), e.Field).String()
+			if id == "" {
+				continue
+			}
+			if result, ok := results[resourceRef{e.Target, id}]; ok {
+				embed[e.Name] = result
+			} else {
+				missing = append(missing, e.Name)
+			}
+		}
+		if missing != nil {
+			embed[missingExpansionsKey] = missing
+		}
+		embeds = append(embeds, embed)
+	}
+
+	c.Set(embedsKey, embeds)
+}
+
+// resourceRef is a resource id to fetch
+type resourceRef struct {
+	res *routes.Resource
+	id  string
+}
+
+// fetchResources fetches refs concurrently, returning the resources found and the error of every other ref
+func (h *handlerV1) fetchResources(ctx context.Context, refs map[resourceRef]bool) (map[resourceRef]proto.Message, map[resourceRef]error) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		sem     = make(chan struct{}, maxFetchCalls)
+		results = make(map[resourceRef]proto.Message, len(refs))
+		errs    = map[resourceRef]error{}
+	)
+
+	for ref := range refs {
+		wg.Add(1)
+		go func(ref resourceRef) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			resp, err := h.getResource(ctx, ref.res, ref.id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[ref] = err
+				return
+			}
+			results[ref] = resp
+		}(ref)
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+// embedExpansions adds the fetched expansions to data, one encoded object or a list of encoded objects
+func (h *handlerV1) embedExpansions(c *gin.Context, data interface{}) (interface{}, error) {
+	embeds, ok := c.Value(embedsKey).([]map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+
+	objects, isList := data.([]interface{})
+	if !isList {
+		objects = []interface{}{data}
+	}
+	for i, object := range objects {
+		obj, ok := object.(map[string]interface{})
+		if !ok || i >= len(embeds) {
+			continue
+		}
+		for key, value := range embeds[i] {
+			if m, ok := value.(proto.Message); ok {
+				encoded, err := h.encoder.Encode(m, nil)
+				if err != nil {
+					return nil, err
+				}
+				value = encoded
+			}
+			obj[key] = value
+		}
+	}
+
+	return data, nil
+}
@@ -1,16 +1,21 @@
 package v1
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
 	"github.com/xfirdavs/api_gateway/config"
+	"github.com/xfirdavs/api_gateway/pkg/cache"
+	"github.com/xfirdavs/api_gateway/pkg/cursor"
+	"github.com/xfirdavs/api_gateway/pkg/idempotency"
 	"github.com/xfirdavs/api_gateway/pkg/logger"
 	services "github.com/xfirdavs/api_gateway/services"
-	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
@@ -28,25 +33,63 @@ type handlerV1 struct {
 	log      logger.Logger
 	cfg      config.Config
 	services services.ServiceManager
+	resolver *services.Resolver
+	cursors  *cursor.Signer
+	encoder  routes.Encoder
+
+	// references remembers the existing referenced resources, modifies has the resources
+	// by their Update and Delete methods, whose calls drop the resource from references
+	references *cache.LRU
+	modifies   map[protoreflect.FullName]*routes.Resource
+	// idempotency stores the responses of the requests sent with an Idempotency-Key, nil when disabled
+	idempotency idempotency.Store
 }
 
 type HandlerV1Options struct {
 	Log      logger.Logger
 	Cfg      config.Config
 	Services services.ServiceManager
+	Resolver *services.Resolver
+	Encoder  routes.Encoder
+	// Resources are the resources of the route table
+	Resources []*routes.Resource
+	// Idempotency stores the idempotent responses, in memory by default
+	Idempotency idempotency.Store
 }
 
 func New(options *HandlerV1Options) *handlerV1 {
+	store := options.Idempotency
+	if store == nil && options.Cfg.IdempotencyTTL > 0 {
+		store = idempotency.NewMemory(options.Cfg.IdempotencyMaxBytes)
+	}
+
+	if options.Cfg.CursorSecret == "" {
+		options.Log.Warn("CURSOR_SECRET is not set, page cursors are signed with a random key and break on restarts and across instances")
+	}
+
+	modifies := map[protoreflect.FullName]*routes.Resource{}
+	for _, res := range options.Resources {
+		modifies[res.Update.FullName()] = res
+		modifies[res.Delete.FullName()] = res
+	}
+
 	return &handlerV1{
 		log:      options.Log,
 		cfg:      options.Cfg,
 		services: options.Services,
+		resolver: options.Resolver,
+		cursors:  cursor.NewSigner(options.Cfg.CursorSecret),
+		encoder:  options.Encoder,
+
+		references:  cache.NewLRU(options.Cfg.ReferenceCacheMaxEntries),
+		modifies:    modifies,
+		idempotency: store,
 	}
 }
 
 func (h *handlerV1) handleErrorResponse(c *gin.Context, code int, message string, err interface{}) {
 	h.log.Error(message, logger.Int("code", code), logger.Any("error", err))
-	c.JSON(code, models.ResponseModel{
+	h.render(c, code, models.ResponseModel{
 		Code:    code,
 		Message: message,
 		Error:   err,
@@ -54,14 +97,58 @@ func (h *handlerV1) handleErrorResponse(c *gin.Context, code int, message string
 }
 
 func (h *handlerV1) handleSuccessResponse(c *gin.Context, code int, message string, data interface{}) {
+	if h.notModified(c, code, data, nil) {
+		return
+	}
+	if negotiated(c) == routes.MIMEProtobuf {
+		if msg, ok := data.(proto.Message); ok {
+			data = pruned(c, msg)
+		}
+		h.renderProto(c, code, data)
+		return
+	}
+
+	data, err := h.encode(c, data)
+	if err != nil {
+		h.handleErrorResponse(c, http.StatusInternalServerError, "error while encoding response", err.Error())
+		return
+	}
+	if data, err = h.embedExpansions(c, data); err != nil {
+		h.handleErrorResponse(c, http.StatusInternalServerError, "error while embedding expansions", err.Error())
+		return
+	}
+
 	h.log.Info(message, logger.Any("response", data))
-	c.JSON(code, models.ResponseModel{
+	h.render(c, code, models.ResponseModel{
 		Code:    code,
 		Message: message,
 		Data:    data,
 	})
 }
 
+func (h *handlerV1) handleListResponse(c *gin.Context, code int, message string, data interface{}, meta *models.PageMeta) {
+	if h.notModified(c, code, data, meta) {
+		return
+	}
+	data, err := h.encode(c, data)
+	if err != nil {
+		h.handleErrorResponse(c, http.StatusInternalServerError, "error while encoding response", err.Error())
+		return
+	}
+	if data, err = h.embedExpansions(c, data); err != nil {
+		h.handleErrorResponse(c, http.StatusInternalServerError, "error while embedding expansions", err.Error())
+		return
+	}
+
+	h.log.Info(message, logger.Any("response", data), logger.Any("meta", meta))
+	h.render(c, code, models.ResponseModel{
+		Code:    code,
+		Message: message,
+		Data:    data,
+		Meta:    meta,
+	})
+}
+
 func (h *handlerV1) ParseQueryParam(c *gin.Context, key string, defaultValue string) (int, error) {
 	valueStr := c.DefaultQuery(key, defaultValue)
 
@@ -85,17 +172,32 @@ func (h *handlerV1) BadRequestResponse(c *gin.Context, err error) {
 	})
 }
 
-func ProtoToStruct(data interface{}, m protoreflect.ProtoMessage) error {
-	jsonMarshaller := protojson.MarshalOptions{
-		AllowPartial:    true,
-		EmitUnpopulated: true,
-	}
-
-	js, err := jsonMarshaller.Marshal(m)
-	if err != nil {
-		return err
+// httpStatusFromError maps the gRPC status code of err to an HTTP status
+func httpStatusFromError(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Canceled:
+		return 499
 	}
 
-	err = json.Unmarshal(js, data)
-	return err
+	return http.StatusInternalServerError
 }
@@ -0,0 +1,227 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/pkg/idempotency"
+	"github.com/xfirdavs/api_gateway/pkg/logger"
+)
+
+// inFlightTTL bounds how long a request that never completes, e.g. because the gateway
+// stopped while serving it, blocks the repeats of its key. The reservation is renewed
+// every inFlightTTL/3 while the request is served.
+const inFlightTTL = time.Minute
+
+// Idempotency makes POST and PATCH requests sent with an Idempotency-Key header safe to retry:
+// the response of the first request of a key is stored for IDEMPOTENCY_TTL and replayed to the
+// repeats of the same request, another request with the key is answered 409 and a repeat while
+// the first request is in flight 425. 5xx responses are not stored, the request may be retried
+// with its key. Keys are scoped to the Authorization header: requests without one share a single
+// key space, so clients must send random keys, e.g. UUIDs. route is nil for the rpc proxy and
+// the batch routes, their bodies are limited by MAX_BODY_SIZE.
+func (h *handlerV1) Idempotency(route *routes.Route) gin.HandlerFunc {
+	max := h.cfg.MaxBodySize
+	if route != nil {
+		max = route.MaxBodySize
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotency.Header)
+		if key == "" || h.idempotency == nil {
+			c.Next()
+			return
+		}
+		if len(key) > idempotency.MaxKeyLength {
+			h.handleErrorResponse(c, http.StatusBadRequest, "invalid idempotency key",
+				idempotency.Header+" is longer than "+strconv.Itoa(idempotency.MaxKeyLength)+" characters")
+			c.Abort()
+			return
+		}
+
+		body, err := routes.ReadBody(c.Request, max)
+		if err != nil {
+			h.handleBindError(c, err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// the response is stored even when the client went away before it
+		ctx := context.WithoutCancel(c.Request.Context())
+		key = scopedKey(c.GetHeader("Authorization"), key)
+		hash := requestHash(c.Request, body)
+
+		record, reserved, err := h.idempotency.Reserve(ctx, key, idempotency.Record{RequestHash: hash}, inFlightTTL)
+		if err != nil {
+			h.handleErrorResponse(c, http.StatusInternalServerError, "error while reserving idempotency key", err.Error())
+			c.Abort()
+			return
+		}
+		if !reserved {
+			h.repeat(c, record, hash)
+			c.Abort()
+			return
+		}
+
+		completed := false
+		defer func() {
+			if !completed {
+				h.releaseKey(ctx, key)
+			}
+		}()
+		stopRenewal := h.renewKey(ctx, key)
+		defer stopRenewal()
+
+		before := c.Writer.Header().Clone()
+		rec := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+		c.Writer = rec.ResponseWriter
+		stopRenewal()
+
+		if rec.Status() >= http.StatusInternalServerError {
+			return
+		}
+		completed = true
+		if err := h.idempotency.Complete(ctx, key, idempotency.Record{
+			RequestHash: hash,
+			Done:        true,
+			Status:      rec.Status(),
+			Header:      changedHeaders(before, rec.Header()),
+			Body:        rec.body.Bytes(),
+		}, h.cfg.IdempotencyTTL); err != nil {
+			h.log.Error("error while storing idempotent response", logger.Error(err))
+			h.releaseKey(ctx, key)
+		}
+	}
+}
+
+// repeat answers a request whose key is known
+func (h *handlerV1) repeat(c *gin.Context, record idempotency.Record, hash string) {
+	switch {
+	case record.RequestHash != hash:
+		h.handleErrorResponse(c, http.StatusConflict, "idempotency key reused",
+			"the key was sent with another request, use a new key for every request")
+	case !record.Done:
+		c.Header("Retry-After", "1")
+		h.handleErrorResponse(c, http.StatusTooEarly, "request in progress",
+			"the first request with this key is still being served, retry later")
+	default:
+		header := c.Writer.Header()
+		for name, values := range record.Header {
+			header[name] = values
+		}
+		header.Set(idempotency.ReplayedHeader, "true")
+		c.Writer.WriteHeader(record.Status)
+		c.Writer.Write(record.Body)
+	}
+}
+
+// renewKey renews the reservation of key until the returned function is called, so that a
+// request served longer than inFlightTTL is not run again by a repeat
+func (h *handlerV1) renewKey(ctx context.Context, key string) func() {
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(inFlightTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := h.idempotency.Renew(ctx, key, inFlightTTL); err != nil {
+					h.log.Error("error while renewing idempotency key", logger.Error(err))
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-stopped
+		})
+	}
+}
+
+func (h *handlerV1) releaseKey(ctx context.Context, key string) {
+	if err := h.idempotency.Release(ctx, key); err != nil {
+		h.log.Error("error while releasing idempotency key", logger.Error(err))
+	}
+}
+
+// scopedKey keeps the keys of different callers apart, a repeat must come with the same credentials.
+// Callers without credentials all get the scope of the empty Authorization header.
+func scopedKey(authorization, key string) string {
+	scope := sha256.Sum256([]byte(authorization))
+
+	return hex.EncodeToString(scope[:8]) + ":" + key
+}
+
+// requestHash identifies a request by method, URL, content type and body
+func requestHash(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{req.Method, req.URL.RequestURI(), req.Header.Get("Content-Type")} {
+		io.WriteString(hash, part)
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// unreplayedHeaders are set by the compression of the response, again on replay
+var unreplayedHeaders = map[string]bool{"Content-Encoding": true, "Content-Length": true, "Vary": true}
+
+// changedHeaders returns the headers the handlers set, those of the outer middleware are set again on replay
+func changedHeaders(before, after http.Header) http.Header {
+	changed := http.Header{}
+	for name, values := range after {
+		if !unreplayedHeaders[name] && !equalValues(before[name], values) {
+			changed[name] = append([]string(nil), values...)
+		}
+	}
+
+	return changed
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// responseRecorder keeps a copy of the body written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
+)
+
+// AdminOnly allows the request only if the Authorization header carries ADMIN_TOKEN.
+// Admin endpoints are disabled when ADMIN_TOKEN is not configured.
+func (h *handlerV1) AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.cfg.AdminToken == "" {
+			h.handleErrorResponse(c, http.StatusForbidden, "admin endpoints are disabled", "ADMIN_TOKEN is not configured")
+			c.Abort()
+			return
+		}
+
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.AdminToken)) != 1 {
+			h.handleErrorResponse(c, http.StatusUnauthorized, "unauthorized", "invalid admin token")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requestKey is the gin context key of the request message bound by Validate
+const requestKey = "request"
+
+// Validate binds the request of route and checks it against the validation rules before
+// the call, answering 400 with every violation, or every unknown field of the body unless
+// the route discards them.
+// The bound request is kept for Proxy. PATCH requests are checked once the patch is applied.
+func (h *handlerV1) Validate(route *routes.Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if route.Action == routes.ActionPatch {
+			c.Next()
+			return
+		}
+
+		if route.Body != "" {
+			body, err := route.ReadBody(c)
+			if err != nil {
+				h.handleBindError(c, err)
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+			if !route.DiscardUnknown {
+				var unknown routes.ValidationError
+				for _, field := range route.UnknownBodyFields(body) {
+					unknown = append(unknown, routes.Violation{Field: field, Description: "is not a known field"})
+				}
+				if len(unknown) > 0 {
+					h.handleErrorResponse(c, http.StatusBadRequest, "validation failed", unknown)
+					c.Abort()
+					return
+				}
+			}
+		}
+
+		req, err := route.Bind(c)
+		if err != nil {
+			h.handleBindError(c, err)
+			c.Abort()
+			return
+		}
+		if err := route.Validation.Validate(req, nil); err != nil {
+			failure := validationFailure(err)
+			h.handleErrorResponse(c, failure.Code, failure.Message, failure.Error)
+			c.Abort()
+			return
+		}
+
+		c.Set(requestKey, req)
+		c.Next()
+	}
+}
+
+// validationFailure is the error response of a request that failed validation, 400 with the
+// violations of a routes.ValidationError
+func validationFailure(err error) *models.ResponseModel {
+	var violations routes.ValidationError
+	if errors.As(err, &violations) {
+		return &models.ResponseModel{Code: http.StatusBadRequest, Message: "validation failed", Error: violations}
+	}
+
+	return &models.ResponseModel{Code: http.StatusInternalServerError, Message: "error while validating request", Error: err.Error()}
+}
@@ -0,0 +1,375 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/api/versioning"
+	"github.com/xfirdavs/api_gateway/pkg/logger"
+)
+
+// subReferencePattern matches a reference to the response body of an earlier request of a
+// batch, ${id.path} where path is dotted and indexes lists by number, e.g. ${list.data.0.id}
+var subReferencePattern = regexp.MustCompile(`\$\{([^.{}]+)\.([^{}]+)\}`)
+
+// forwardedHeaders are the headers of a batch request its requests are sent with
+var forwardedHeaders = []string{"Authorization", "Cache-Control"}
+
+// subCall is a request of a batch, its response is set once done is closed
+type subCall struct {
+	req  models.SubRequest
+	done chan struct{}
+	resp models.SubResponse
+
+	// doc is the decoded JSON body of the response, for the references of later requests
+	doc interface{}
+}
+
+func (call *subCall) fail(code int, message string, err interface{}) {
+	call.resp.Code = code
+	call.resp.Headers = map[string]string{"Content-Type": binding.MIMEJSON}
+	call.resp.Body = models.ResponseModel{Code: code, Message: message, Error: err}
+}
+
+// MultiRequest godoc
+// @ID batch
+// @Router /v1/batch [POST]
+// @Summary sends several requests at once
+// @Description serves every request of the body with the routes of the gateway, without network hops, and returns their responses in the order sent. Requests run concurrently, BATCH_CONCURRENCY at a time, and carry the Authorization and Cache-Control headers of the batch. A path without version prefix is served in the version of the batch. Path, header values and body strings may reference the JSON response body of an earlier request by its id, e.g. /v2/company/${company.data.id}; the request then waits for it and is answered 424 when it failed.
+// @Tags batch
+// @Accept json,application/msgpack,application/yaml
+// @Produce json,application/msgpack,application/yaml
+// @Param body body models.MultiRequest true "requests"
+// @Success 207 {object} models.ResponseModel{data=[]models.SubResponse} "Multi-Status"
+// @Response 400 {object} models.ResponseModel{error=string} "Bad Request"
+// @Response 406 {object} models.ResponseModel{error=string} "Not Acceptable"
+// @Response 413 {object} models.ResponseModel{error=string} "Request Entity Too Large"
+// @Response 415 {object} models.ResponseModel{error=string} "Unsupported Media Type"
+// @Failure 500 {object} models.ResponseModel{error=string} "Server Error"
+func (h *handlerV1) MultiRequest(router http.Handler, version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.ContentType() == routes.MIMEProtobuf {
+			h.handleErrorResponse(c, http.StatusUnsupportedMediaType, "unsupported media type",
+				"a batch is sent as JSON, MessagePack or YAML")
+			return
+		}
+
+		body, err := routes.ReadBody(c.Request, h.cfg.MaxBodySize)
+		if err != nil {
+			h.handleBindError(c, err)
+			return
+		}
+		var req models.MultiRequest
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			h.handleBindError(c, &routes.DecodeError{Reason: "invalid batch: " + err.Error()})
+			return
+		}
+		switch {
+		case len(req.Requests) == 0:
+			h.handleErrorResponse(c, http.StatusBadRequest, "invalid batch", "requests is empty")
+			return
+		case len(req.Requests) > h.cfg.BatchMaxItems:
+			h.handleErrorResponse(c, http.StatusBadRequest, "invalid batch",
+				fmt.Sprintf("a batch has at most %d requests", h.cfg.BatchMaxItems))
+			return
+		}
+
+		calls, ids := make([]*subCall, 0, len(req.Requests)), map[string]int{}
+		for i, r := range req.Requests {
+			if r.ID != "" {
+				if _, ok := ids[r.ID]; ok {
+					h.handleErrorResponse(c, http.StatusBadRequest, "invalid batch", "id "+r.ID+" is used twice")
+					return
+				}
+				ids[r.ID] = i
+			}
+			calls = append(calls, &subCall{req: r, done: make(chan struct{}), resp: models.SubResponse{Index: i, ID: r.ID}})
+		}
+
+		header := http.Header{}
+		for _, name := range forwardedHeaders {
+			if value := c.GetHeader(name); value != "" {
+				header.Set(name, value)
+			}
+		}
+
+		// every request waits for the ones it references before taking its turn
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, h.cfg.BatchConcurrency)
+		for _, call := range calls {
+			wg.Add(1)
+			go func(call *subCall) {
+				defer wg.Done()
+				defer close(call.done)
+
+				if !h.awaitReferences(call, calls, ids) {
+					return
+				}
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				h.serveSubRequest(c.Request.Context(), router, version, header, call, calls, ids)
+			}(call)
+		}
+		wg.Wait()
+
+		results, failed := make([]models.SubResponse, 0, len(calls)), 0
+		for _, call := range calls {
+			results = append(results, call.resp)
+			if call.resp.Code >= http.StatusBadRequest {
+				failed++
+			}
+		}
+
+		h.log.Info("ok", logger.String("batch", c.Request.URL.Path),
+			logger.Int("requests", len(calls)), logger.Int("failed", failed))
+		h.render(c, http.StatusMultiStatus, models.ResponseModel{
+			Code:    http.StatusMultiStatus,
+			Message: "ok",
+			Data:    results,
+		})
+	}
+}
+
+// awaitReferences waits for the requests call references, answering 400 when one is not
+// an earlier request of the batch and 424 when one failed
+func (h *handlerV1) awaitReferences(call *subCall, calls []*subCall, ids map[string]int) bool {
+	texts := []string{call.req.Path, string(call.req.Body)}
+	for _, value := range call.req.Headers {
+		texts = append(texts, value)
+	}
+
+	for _, text := range texts {
+		for _, match := range subReferencePattern.FindAllStringSubmatch(text, -1) {
+			i, ok := ids[match[1]]
+			if !ok || i >= call.resp.Index {
+				call.fail(http.StatusBadRequest, "invalid reference", match[0]+" does not name an earlier request")
+				return false
+			}
+
+			dep := calls[i]
+			<-dep.done
+			if dep.resp.Code >= http.StatusBadRequest {
+				call.fail(http.StatusFailedDependency, "failed dependency", "request "+match[1]+" failed")
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// serveSubRequest resolves the references of call and serves it with router
+func (h *handlerV1) serveSubRequest(ctx context.Context, router http.Handler, version string, header http.Header,
+	call *subCall, calls []*subCall, ids map[string]int) {
+	lookup := func(id, path string) (interface{}, error) {
+		return lookupPath(calls[ids[id]].doc, path)
+	}
+
+	path, err := resolveReferences(call.req.Path, lookup)
+	if err != nil {
+		call.fail(http.StatusBadRequest, "invalid reference", err.Error())
+		return
+	}
+	if !strings.HasPrefix(path, "/") {
+		call.fail(http.StatusBadRequest, "invalid request", "path must start with /")
+		return
+	}
+	if first := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]; !versioning.IsVersion(first) {
+		path = "/" + version + path
+	}
+	if segments := strings.SplitN(path, "/", 3); len(segments) == 3 && strings.SplitN(segments[2], "?", 2)[0] == "batch" {
+		call.fail(http.StatusBadRequest, "invalid request", "batches can not be nested")
+		return
+	}
+
+	var body io.Reader
+	if len(call.req.Body) > 0 {
+		data, err := resolveBody(call.req.Body, lookup)
+		if err != nil {
+			call.fail(http.StatusBadRequest, "invalid reference", err.Error())
+			return
+		}
+		body = bytes.NewReader(data)
+	}
+
+	method := strings.ToUpper(call.req.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, body)
+	if err != nil {
+		call.fail(http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	req.Header = header.Clone()
+	for name, value := range call.req.Headers {
+		if value, err = resolveReferences(value, lookup); err != nil {
+			call.fail(http.StatusBadRequest, "invalid reference", err.Error())
+			return
+		}
+		req.Header.Set(name, value)
+	}
+	// the response is embedded in the batch response as it is
+	req.Header.Set("Accept", binding.MIMEJSON)
+	req.Header.Del("Accept-Encoding")
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", binding.MIMEJSON)
+	}
+
+	w := &subResponseWriter{header: http.Header{}}
+	router.ServeHTTP(w, req)
+
+	call.resp.Code = w.code
+	if call.resp.Code == 0 {
+		call.resp.Code = http.StatusOK
+	}
+	call.resp.Headers = map[string]string{}
+	for name := range w.header {
+		call.resp.Headers[name] = w.header.Get(name)
+	}
+	if w.body.Len() == 0 {
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(w.header.Get("Content-Type")); mediaType != binding.MIMEJSON {
+		call.resp.Body = w.body.String()
+		return
+	}
+	call.resp.Body = json.RawMessage(w.body.Bytes())
+	dec := json.NewDecoder(bytes.NewReader(w.body.Bytes()))
+	dec.UseNumber()
+	if err := dec.Decode(&call.doc); err != nil {
+		call.resp.Body = w.body.String()
+	}
+}
+
+// resolveReferences replaces the references in s with the text of their values
+func resolveReferences(s string, lookup func(id, path string) (interface{}, error)) (string, error) {
+	var err error
+	resolved := subReferencePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		match := subReferencePattern.FindStringSubmatch(ref)
+		value, lookupErr := lookup(match[1], match[2])
+		if lookupErr != nil {
+			err = fmt.Errorf("%s: %w", ref, lookupErr)
+			return ref
+		}
+		if text, ok := value.(string); ok {
+			return text
+		}
+		data, _ := json.Marshal(value)
+		return string(data)
+	})
+
+	return resolved, err
+}
+
+// resolveBody replaces the references in the strings of a JSON body, a string that is
+// a single reference is replaced by the value, keeping its type
+func resolveBody(body json.RawMessage, lookup func(id, path string) (interface{}, error)) ([]byte, error) {
+	if !subReferencePattern.Match(body) {
+		return body, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var resolve func(v interface{}) (interface{}, error)
+	resolve = func(v interface{}) (interface{}, error) {
+		var err error
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, item := range v {
+				if v[key], err = resolve(item); err != nil {
+					return nil, err
+				}
+			}
+		case []interface{}:
+			for i, item := range v {
+				if v[i], err = resolve(item); err != nil {
+					return nil, err
+				}
+			}
+		case string:
+			if match := subReferencePattern.FindStringSubmatch(v); match != nil && match[0] == v {
+				value, err := lookup(match[1], match[2])
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", v, err)
+				}
+				return value, nil
+			}
+			return resolveReferences(v, lookup)
+		}
+		return v, nil
+	}
+
+	resolved, err := resolve(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(resolved)
+}
+
+// lookupPath returns the value at the dotted path of a JSON document, list items are indexed by number
+func lookupPath(doc interface{}, path string) (interface{}, error) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("%s is not in the response", path)
+			}
+			doc = value
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("%s is not in the response", path)
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%s is not in the response", path)
+		}
+	}
+
+	return doc, nil
+}
+
+// subResponseWriter keeps the response of a request of a batch
+type subResponseWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (w *subResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *subResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *subResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
@@ -0,0 +1,240 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/pkg/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// formatKey is the gin context key of the negotiated response media type
+const formatKey = "format"
+
+// Negotiate picks the response format of the Accept header, answering 406 when none is offered,
+// and converts MessagePack, YAML and protobuf request bodies to JSON for the handlers, answering
+// 415 for other formats. route is nil for handlers that decode protobuf bodies themselves.
+func (h *handlerV1) Negotiate(route *routes.Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept")
+		format := routes.NegotiateFormat(c.GetHeader("Accept"))
+		if format == "" {
+			h.handleErrorResponse(c, http.StatusNotAcceptable, "not acceptable",
+				"supported formats: "+strings.Join(routes.ResponseFormats, ", "))
+			c.Abort()
+			return
+		}
+		c.Set(formatKey, format)
+
+		mediaType := c.ContentType()
+		if !hasBody(c.Request) || routes.IsJSON(mediaType) || route == nil && mediaType == routes.MIMEProtobuf {
+			c.Next()
+			return
+		}
+
+		max, md := h.cfg.MaxBodySize, protoreflect.MessageDescriptor(nil)
+		if route != nil {
+			max, md = route.MaxBodySize, route.BodyMessage()
+		}
+		body, err := routes.ReadBody(c.Request, max)
+		if err == nil {
+			body, err = routes.TranscodeBody(mediaType, body, md)
+		}
+		if errors.Is(err, routes.ErrUnsupportedMediaType) {
+			h.handleErrorResponse(c, http.StatusUnsupportedMediaType, "unsupported media type", err.Error())
+			c.Abort()
+			return
+		}
+		if err != nil {
+			h.handleBindError(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Request.Header.Set("Content-Type", binding.MIMEJSON)
+
+		c.Next()
+	}
+}
+
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody && req.ContentLength != 0
+}
+
+// negotiated returns the response media type of the request, JSON when it was not negotiated
+func negotiated(c *gin.Context) string {
+	if format := c.GetString(formatKey); format != "" {
+		return format
+	}
+
+	return binding.MIMEJSON
+}
+
+// render writes the response envelope in the negotiated format, binary protobuf responses
+// carry the error envelope as a google.rpc.Status
+func (h *handlerV1) render(c *gin.Context, code int, response models.ResponseModel) {
+	setCacheHeaders(c)
+	format := negotiated(c)
+	if format == binding.MIMEJSON {
+		c.JSON(code, response)
+		return
+	}
+
+	if format == routes.MIMEProtobuf {
+		c.ProtoBuf(code, errorStatus(code, response.Message, response.Error))
+		return
+	}
+
+	doc, err := plainDocument(response)
+	if err != nil {
+		h.log.Error("error while encoding response", logger.Error(err))
+		c.JSON(http.StatusInternalServerError, models.ResponseModel{
+			Code:    http.StatusInternalServerError,
+			Message: "error while encoding response",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if format == routes.MIMEMsgPack {
+		c.Render(code, render.MsgPack{Data: doc})
+		return
+	}
+	c.Header("Content-Type", routes.MIMEYAML+"; charset=utf-8")
+	c.YAML(code, doc)
+}
+
+// renderProto writes the binary proto of data, responses that are not a message are not acceptable as protobuf
+func (h *handlerV1) renderProto(c *gin.Context, code int, data interface{}) {
+	msg, ok := data.(proto.Message)
+	if !ok {
+		h.handleErrorResponse(c, http.StatusNotAcceptable, "not acceptable",
+			"the response of "+c.Request.URL.Path+" is not a message and can not be encoded as "+routes.MIMEProtobuf)
+		return
+	}
+
+	setCacheHeaders(c)
+	c.ProtoBuf(code, msg)
+}
+
+// pruned returns a copy of msg pruned to the sparse fieldset of the request, msg itself without one
+func pruned(c *gin.Context, msg proto.Message) proto.Message {
+	selection, ok := c.Value(fieldsKey).(*routes.FieldSelection)
+	if !ok {
+		return msg
+	}
+
+	msg = proto.Clone(msg)
+	selection.Prune(msg.ProtoReflect())
+	return msg
+}
+
+// plainDocument returns v as a generic JSON document with plain numbers, for the encoders
+// of the other formats that do not know json.Number
+func plainDocument(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return plainNumbers(doc), nil
+}
+
+func plainNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = plainNumbers(value)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = plainNumbers(item)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+
+	return v
+}
+
+// errorStatus returns the google.rpc.Status of an error response, with the error as a google.protobuf.Value detail
+func errorStatus(code int, message string, detail interface{}) proto.Message {
+	s := status.New(grpcCode(code), message)
+
+	if err, ok := detail.(error); ok {
+		detail = err.Error()
+	}
+	if detail != nil {
+		doc, err := plainDocument(detail)
+		if err == nil {
+			if value, err := structpb.NewValue(doc); err == nil {
+				if withDetails, err := s.WithDetails(value); err == nil {
+					s = withDetails
+				}
+			}
+		}
+	}
+
+	return s.Proto()
+}
+
+// grpcCode maps the HTTP status of an error response back to a gRPC code, see httpStatusFromError
+func grpcCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity,
+		http.StatusNotAcceptable, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499:
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+
+	if code >= http.StatusInternalServerError {
+		return codes.Internal
+	}
+
+	return codes.Unknown
+}
@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/config"
+	"github.com/xfirdavs/api_gateway/genproto/company_service"
+	"github.com/xfirdavs/api_gateway/pkg/logger"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNegotiate(t *testing.T) {
+	h := &handlerV1{log: logger.New("error", "test"), cfg: config.Config{MaxBodySize: 1 << 20}}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	// echo answers the name of the JSON body as a company
+	engine.POST("/echo", h.Negotiate(nil), func(c *gin.Context) {
+		var body struct{ Name string }
+		if err := c.ShouldBindJSON(&body); err != nil {
+			h.handleBindError(c, err)
+			return
+		}
+		h.handleSuccessResponse(c, http.StatusOK, "ok", &company_service.Company{Name: body.Name})
+	})
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        []byte
+		// code and format of the response, company the name answered
+		code    int
+		format  string
+		company string
+	}{
+		{name: "json", contentType: "application/json", body: []byte(`{"name":"acme"}`),
+			code: http.StatusOK, format: "application/json", company: "acme"},
+		{name: "yaml to msgpack", accept: "application/msgpack", contentType: "application/yaml", body: []byte("name: acme\n"),
+			code: http.StatusOK, format: routes.MIMEMsgPack, company: "acme"},
+		{name: "msgpack to yaml", accept: "application/x-yaml", contentType: "application/x-msgpack", body: []byte("\x81\xa4name\xa4acme"),
+			code: http.StatusOK, format: routes.MIMEYAML, company: "acme"},
+		{name: "json to protobuf", accept: "application/x-protobuf", contentType: "application/json", body: []byte(`{"name":"acme"}`),
+			code: http.StatusOK, format: routes.MIMEProtobuf, company: "acme"},
+		{name: "not acceptable", accept: "text/html", contentType: "application/json", body: []byte(`{"name":"acme"}`),
+			code: http.StatusNotAcceptable, format: "application/json"},
+		{name: "unsupported media type", contentType: "application/xml", body: []byte("<name>acme</name>"),
+			code: http.StatusUnsupportedMediaType, format: "application/json"},
+		{name: "invalid yaml", accept: "application/yaml", contentType: "application/yaml", body: []byte("name: [acme\n"),
+			code: http.StatusBadRequest, format: routes.MIMEYAML},
+		{name: "error as status", accept: "application/x-protobuf", contentType: "application/xml", body: []byte("<name/>"),
+			code: http.StatusUnsupportedMediaType, format: routes.MIMEProtobuf},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Fatalf("code %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary %q, want Accept", got)
+			}
+			if got, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")); got != tt.format {
+				t.Errorf("Content-Type %q, want %s", got, tt.format)
+			}
+
+			if tt.format == routes.MIMEProtobuf {
+				checkProtobuf(t, w.Body.Bytes(), tt.code, tt.company)
+				return
+			}
+			data := w.Body.Bytes()
+			if tt.format != "application/json" {
+				var err error
+				if data, err = routes.TranscodeBody(tt.format, data, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var resp struct {
+				Code int
+				Data struct{ Name string }
+			}
+			if err := json.Unmarshal(data, &resp); err != nil {
+				t.Fatalf("%s: %v", data, err)
+			}
+			if resp.Code != tt.code || resp.Data.Name != tt.company {
+				t.Errorf("response %s, want code %d and name %q", data, tt.code, tt.company)
+			}
+		})
+	}
+}
+
+// checkProtobuf checks a binary response: the company of a success, a google.rpc.Status otherwise
+func checkProtobuf(t *testing.T, body []byte, code int, name string) {
+	t.Helper()
+	if code != http.StatusOK {
+		var s spb.Status
+		if err := proto.Unmarshal(body, &s); err != nil {
+			t.Fatal(err)
+		}
+		if codes.Code(s.Code) != grpcCode(code) || s.Message == "" {
+			t.Errorf("status %v, want code %s", &s, grpcCode(code))
+		}
+		return
+	}
+
+	var company company_service.Company
+	if err := proto.Unmarshal(body, &company); err != nil {
+		t.Fatal(err)
+	}
+	if company.Name != name {
+		t.Errorf("company %v, want the name %q", &company, name)
+	}
+}
@@ -0,0 +1,194 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/pkg/cursor"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// listResource serves the list route of a resource on top of its offset RPC:
+// a cursor replaces offset and limit, the limit is capped by the resource,
+// sort is forwarded or applied by the gateway, and the response has the page
+// meta and Link headers to the neighbouring pages
+func (h *handlerV1) listResource(c *gin.Context, route *routes.Route) {
+	res := route.Resource
+
+	req, err := h.bind(c, route)
+	if err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+	msg := req.ProtoReflect()
+
+	query := pageQuery(c.Request.URL.Query())
+	if value := c.Query(routes.ParamCursor); value != "" {
+		cur, err := h.cursors.Decode(value)
+		if err == nil && cur.Query != query {
+			err = fmt.Errorf("%w: the cursor belongs to another query", cursor.ErrInvalid)
+		}
+		if err != nil {
+			h.handleErrorResponse(c, http.StatusBadRequest, "invalid cursor", err.Error())
+			return
+		}
+
+		limit := cur.Limit
+		if c.Query("limit") != "" {
+			limit = int(routes.GetField(msg, "limit").Int())
+		}
+		if err := setPage(msg, cur.Offset, limit); err != nil {
+			h.handleErrorResponse(c, http.StatusInternalServerError, "error while binding request", err.Error())
+			return
+		}
+	}
+
+	limit := int(routes.GetField(msg, "limit").Int())
+	offset := int(routes.GetField(msg, "offset").Int())
+	if limit < 1 || limit > res.MaxPageSize {
+		h.handleErrorResponse(c, http.StatusBadRequest, "invalid limit", fmt.Sprintf("limit must be between 1 and %d", res.MaxPageSize))
+		return
+	}
+	if offset < 0 {
+		h.handleErrorResponse(c, http.StatusBadRequest, "invalid offset", "offset must not be negative")
+		return
+	}
+
+	var keys []routes.SortKey
+	if expr := c.Query(routes.ParamSort); expr != "" && res.Sort != nil {
+		if keys, err = res.Sort.Parse(expr); err != nil {
+			h.handleErrorResponse(c, http.StatusBadRequest, "unsupported sort", err.Error())
+			return
+		}
+	}
+
+	// without a backend sort field the gateway fetches the whole list, sorts it and cuts the page
+	sortInGateway := len(keys) > 0 && res.Sort.Field == ""
+	if len(keys) > 0 && !sortInGateway {
+		err = res.Sort.Forward(msg, keys)
+	}
+	if sortInGateway {
+		err = setPage(msg, 0, res.Sort.MaxItems)
+	}
+	if err != nil {
+		h.handleErrorResponse(c, http.StatusInternalServerError, "error while binding request", err.Error())
+		return
+	}
+
+	resp, err := h.invoke(c.Request.Context(), route.RPC, req)
+	if err != nil {
+		h.handleResourceError(c, route, "", err)
+		return
+	}
+
+	items, total := res.Page(resp)
+	if sortInGateway {
+		if total > int64(res.Sort.MaxItems) {
+			h.handleErrorResponse(c, http.StatusBadRequest, "unsupported sort",
+				fmt.Sprintf("%s lists of more than %d items can not be sorted, narrow them with filters", res.Name, res.Sort.MaxItems))
+			return
+		}
+		res.Sort.Apply(items, keys)
+		items = window(items, offset, limit)
+	}
+	meta := &models.PageMeta{
+		Total:   total,
+		Limit:   limit,
+		HasMore: int64(offset+len(items)) < total,
+	}
+
+	var links []string
+	if meta.HasMore {
+		meta.NextCursor = h.cursors.Encode(cursor.Cursor{Offset: offset + len(items), Limit: limit, Query: query})
+		links = append(links, pageLink(c.Request.URL, meta.NextCursor, "next"))
+	}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		meta.PrevCursor = h.cursors.Encode(cursor.Cursor{Offset: prev, Limit: limit, Query: query})
+		links = append(links, pageLink(c.Request.URL, meta.PrevCursor, "prev"))
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+
+	// binary responses are the list message itself, the page meta is in the Link header
+	if negotiated(c) == routes.MIMEProtobuf {
+		for i, item := range items {
+			items[i] = pruned(c, item.(proto.Message))
+		}
+		if h.notModified(c, route.Status, items, meta) {
+			return
+		}
+		h.renderProto(c, route.Status, res.PageMessage(resp, items))
+		return
+	}
+
+	h.expand(c, items)
+	h.handleListResponse(c, route.Status, "ok", items, meta)
+}
+
+func setPage(msg protoreflect.Message, offset, limit int) error {
+	if err := routes.SetField(msg, "offset", []string{strconv.Itoa(offset)}); err != nil {
+		return err
+	}
+
+	return routes.SetField(msg, "limit", []string{strconv.Itoa(limit)})
+}
+
+// window returns the items of the page at offset
+func window(items []interface{}, offset, limit int) []interface{} {
+	if offset >= len(items) {
+		return []interface{}{}
+	}
+	if end := offset + limit; end < len(items) {
+		return items[offset:end]
+	}
+
+	return items[offset:]
+}
+
+// pageQuery identifies the filters of a list request, the page parameters and fields excluded,
+// so that a cursor is only used with the query it was issued for
+func pageQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		switch key {
+		case routes.ParamCursor, routes.ParamFields, "limit", "offset":
+		default:
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%s&", key, strings.Join(query[key], ","))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)[:8])
+}
+
+// pageLink returns an RFC 8288 link to the page of cursor
+func pageLink(u *url.URL, cur, rel string) string {
+	query := u.Query()
+	query.Del("offset")
+	query.Set(routes.ParamCursor, cur)
+
+	return fmt.Sprintf("<%s?%s>; rel=%q", u.Path, query.Encode(), rel)
+}
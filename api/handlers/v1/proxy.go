@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Proxy returns the handler of a declarative route: it binds the request
+// message, calls the route's gRPC method and writes the response.
+func (h *handlerV1) Proxy(route *routes.Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.parseFields(c, route) || !h.parseExpand(c, route) {
+			return
+		}
+
+		if route.Resource != nil {
+			h.resourceCall(c, route)
+			return
+		}
+
+		req, err := h.bind(c, route)
+		if err != nil {
+			h.handleBindError(c, err)
+			return
+		}
+		// the current resource is read for If-Match and to check that an update only changes mutable fields
+		res := route.Modifies
+		update := res != nil && route.RPC == res.Update && res.RestrictsUpdates()
+		if res != nil && (c.GetHeader(routes.HeaderIfMatch) != "" || update) {
+			current, ok := h.currentResource(c, route, routes.GetField(req.ProtoReflect(), res.IDField).String())
+			if !ok {
+				return
+			}
+			if update {
+				if failure := immutableFailure(res, current, req); failure != nil {
+					h.handleErrorResponse(c, failure.Code, failure.Message, failure.Error)
+					return
+				}
+			}
+		}
+		if !h.checkWrite(c, route, req) {
+			return
+		}
+
+		resp, err := h.invoke(c.Request.Context(), route.RPC, req)
+		if err != nil {
+			h.handleErrorResponse(c, http.StatusInternalServerError, "error while calling "+route.Selector, err)
+			return
+		}
+
+		h.handleSuccessResponse(c, route.Status, "ok", route.Response(resp))
+	}
+}
+
+// fieldsKey is the gin context key of the sparse fieldset of the request
+const fieldsKey = "fields"
+
+// parseFields reads the sparse fieldset from the fields query parameter or the
+// X-Fields header before the call, answering 400 when it is invalid
+func (h *handlerV1) parseFields(c *gin.Context, route *routes.Route) bool {
+	expr := c.Query(routes.ParamFields)
+	if expr == "" {
+		expr = c.GetHeader(routes.HeaderFields)
+	}
+	if expr == "" {
+		return true
+	}
+
+	md := route.DataMessage()
+	if md == nil {
+		h.handleErrorResponse(c, http.StatusBadRequest, "invalid fields", "fields can not be selected on "+route.Path)
+		return false
+	}
+	selection, err := routes.ParseFieldSelection(md, expr)
+	if err != nil {
+		h.handleErrorResponse(c, http.StatusBadRequest, "invalid fields", err.Error())
+		return false
+	}
+
+	c.Set(fieldsKey, selection)
+	return true
+}
+
+// encode encodes the messages of data with protojson, pruned to the sparse fieldset of the request, if any
+func (h *handlerV1) encode(c *gin.Context, data interface{}) (interface{}, error) {
+	selection, _ := c.Value(fieldsKey).(*routes.FieldSelection)
+
+	return h.encoder.EncodeData(data, selection)
+}
+
+// bind returns the request message of route, bound by Validate or from the request
+func (h *handlerV1) bind(c *gin.Context, route *routes.Route) (proto.Message, error) {
+	if req, ok := c.Value(requestKey).(proto.Message); ok {
+		return req, nil
+	}
+
+	return route.Bind(c)
+}
+
+// handleBindError answers 400, listing every invalid query parameter when filters rejected the
+// request and with the JSON path of the offending value when the body could not be decoded,
+// 413 when the body is too large
+func (h *handlerV1) handleBindError(c *gin.Context, err error) {
+	failure := bindFailure(err)
+	h.handleErrorResponse(c, failure.Code, failure.Message, failure.Error)
+}
+
+// bindFailure is the error response of handleBindError
+func bindFailure(err error) *models.ResponseModel {
+	var filterErr routes.FilterError
+	if errors.As(err, &filterErr) {
+		return &models.ResponseModel{Code: http.StatusBadRequest, Message: "invalid query parameters", Error: []string(filterErr)}
+	}
+	if errors.Is(err, routes.ErrBodyTooLarge) {
+		return &models.ResponseModel{Code: http.StatusRequestEntityTooLarge, Message: "request body too large", Error: err.Error()}
+	}
+	var decodeErr *routes.DecodeError
+	if errors.As(err, &decodeErr) {
+		return &models.ResponseModel{Code: http.StatusBadRequest, Message: "invalid body", Error: decodeErr}
+	}
+
+	return &models.ResponseModel{Code: http.StatusBadRequest, Message: "error while binding request", Error: err.Error()}
+}
+
+// invoke calls the gRPC method md on the connection of its service, an Update or Delete
+// drops the resource from the reference cache
+func (h *handlerV1) invoke(ctx context.Context, md protoreflect.MethodDescriptor, req proto.Message) (proto.Message, error) {
+	conn, err := h.services.Conn(string(md.Parent().FullName()))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := routes.NewMessage(md.Output())
+	err = conn.Invoke(ctx, routes.FullMethod(md), req, resp)
+	h.forgetReference(ctx, md, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/pkg/attrtype"
+	"github.com/xfirdavs/api_gateway/pkg/cache"
+	"github.com/xfirdavs/api_gateway/pkg/logger"
+	"github.com/xfirdavs/api_gateway/pkg/util"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// checkWrite checks the request of a route creating or updating a resource before the call:
+// its attribute type must be known, every id it references must name an existing resource
+// and the values of typed references must match the type of the referenced resource, unless
+// that type is one the registry does not know, stored before it existed. It answers 422 listing the problems otherwise.
+func (h *handlerV1) checkWrite(c *gin.Context, route *routes.Route, req proto.Message) bool {
+	if failure := h.writeFailure(c.Request.Context(), route, req); failure != nil {
+		h.handleErrorResponse(c, failure.Code, failure.Message, failure.Error)
+		return false
+	}
+
+	return true
+}
+
+// writeFailure makes the checks of checkWrite, returning the error response when one fails
+func (h *handlerV1) writeFailure(ctx context.Context, route *routes.Route, req proto.Message) *models.ResponseModel {
+	if path, typ := route.AttributeType(req); path != "" && (route.Action != routes.ActionPatch || route.Resource.MaskField == nil) {
+		if _, err := attrtype.Parse(typ); err != nil {
+			return &models.ResponseModel{Code: http.StatusUnprocessableEntity, Message: "invalid attribute type", Error: path + ": " + err.Error()}
+		}
+	}
+
+	values := route.ReferencedIDs(req)
+	if len(values) == 0 {
+		return nil
+	}
+
+	resources, failure := h.fetchReferences(ctx, values)
+	if failure != nil {
+		return failure
+	}
+
+	var invalid []string
+	for _, v := range values {
+		if !util.IsValidUUID(v.ID) {
+			invalid = append(invalid, fmt.Sprintf("%s: %q is not a valid UUID", v.Path, v.ID))
+		} else if resources[resourceRef{v.Reference.Target, v.ID}] == nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %s %s does not exist", v.Path, v.Reference.Target.Name, v.ID))
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return &models.ResponseModel{Code: http.StatusUnprocessableEntity, Message: "invalid references", Error: invalid}
+	}
+
+	for _, v := range values {
+		if v.Reference.Value == "" {
+			continue
+		}
+		target := v.Reference.Target
+		typ := routes.GetField(resources[resourceRef{target, v.ID}].ProtoReflect(), target.TypeField).String()
+		validate, err := attrtype.Parse(typ)
+		if err != nil {
+			// the type was stored before the registry existed, the value is taken as is
+			h.log.Warn("attribute type is not in the registry, value not validated", logger.String("resource", target.Name),
+				logger.String("id", v.ID), logger.String("type", typ))
+			continue
+		}
+		if err := validate(v.Value); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %q %v", v.ValuePath, v.Value, err))
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return &models.ResponseModel{Code: http.StatusUnprocessableEntity, Message: "invalid attribute values", Error: invalid}
+	}
+
+	return nil
+}
+
+// fetchReferences returns the existing resources referenced by values, taken from the
+// reference cache or fetched and cached. It returns the error response when a fetch fails
+// for another reason than a missing resource.
+func (h *handlerV1) fetchReferences(ctx context.Context, values []routes.RefValue) (map[resourceRef]proto.Message, *models.ResponseModel) {
+	resources := map[resourceRef]proto.Message{}
+	refs := map[resourceRef]bool{}
+	for _, v := range values {
+		if !util.IsValidUUID(v.ID) {
+			continue
+		}
+		ref := resourceRef{v.Reference.Target, v.ID}
+		if cached, ok := h.references.Get(ctx, referenceKey(ref)); ok {
+			msg := routes.NewMessage(ref.res.Get.Output())
+			if err := proto.Unmarshal(cached.Value, msg); err == nil {
+				resources[ref] = msg
+				continue
+			}
+		}
+		refs[ref] = true
+	}
+
+	found, errs := h.fetchResources(ctx, refs)
+	for ref, err := range errs {
+		if !errors.Is(err, errResourceNotFound) {
+			return nil, &models.ResponseModel{Code: httpStatusFromError(err), Message: "error while checking " + ref.res.Name + " " + ref.id, Error: err.Error()}
+		}
+	}
+	for ref, resp := range found {
+		if data, err := proto.Marshal(resp); err == nil {
+			h.references.Set(ctx, referenceKey(ref), cache.Entry{Value: data, Stored: time.Now()}, h.cfg.ReferenceCacheTTL)
+		}
+		resources[ref] = resp
+	}
+
+	return resources, nil
+}
+
+// forgetReference drops the resource an Update or Delete call of md changes from the
+// reference cache, whether the call succeeded or not: it may have been applied anyway
+func (h *handlerV1) forgetReference(ctx context.Context, md protoreflect.MethodDescriptor, req proto.Message) {
+	res, ok := h.modifies[md.FullName()]
+	if !ok {
+		return
+	}
+	id := routes.GetField(req.ProtoReflect(), res.IDField).String()
+	h.references.Delete(ctx, referenceKey(resourceRef{res, id}))
+}
+
+func referenceKey(ref resourceRef) string {
+	return ref.res.Name + "/" + ref.id
+}
@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/pkg/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var errResourceNotFound = errors.New(ErrNotFound)
+
+// resourceCall serves the RESTful routes of a resource: the path id must be a UUID,
+// unknown ids answer 404, creates set Location and deletes answer 204 without a body
+func (h *handlerV1) resourceCall(c *gin.Context, route *routes.Route) {
+	res := route.Resource
+	ctx := c.Request.Context()
+
+	id := c.Param(res.IDField)
+	if route.Action != routes.ActionCreate && route.Action != routes.ActionList && !util.IsValidUUID(id) {
+		h.handleErrorResponse(c, http.StatusBadRequest, "invalid id", id+" is not a valid UUID")
+		return
+	}
+
+	switch route.Action {
+	case routes.ActionPatch:
+		h.patchResource(c, route, id)
+		return
+	case routes.ActionList:
+		h.listResource(c, route)
+		return
+	}
+
+	req, err := h.bind(c, route)
+	if err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	if route.Action == routes.ActionUpdate || route.Action == routes.ActionDelete {
+		current, ok := h.currentResource(c, route, id)
+		if !ok {
+			return
+		}
+		if route.Action == routes.ActionUpdate {
+			if failure := immutableFailure(res, current, req); failure != nil {
+				h.handleErrorResponse(c, failure.Code, failure.Message, failure.Error)
+				return
+			}
+		}
+	}
+	if !h.checkWrite(c, route, req) {
+		return
+	}
+
+	resp, err := h.invoke(ctx, route.RPC, req)
+	if err == nil && route.Action == routes.ActionGet && routes.GetField(resp.ProtoReflect(), res.IDField).String() == "" {
+		err = errResourceNotFound
+	}
+	if err != nil {
+		h.handleResourceError(c, route, id, err)
+		return
+	}
+
+	switch route.Action {
+	case routes.ActionCreate:
+		if created := routes.GetField(resp.ProtoReflect(), res.IDField).String(); created != "" {
+			c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+created)
+		}
+	case routes.ActionDelete:
+		c.Status(http.StatusNoContent)
+		return
+	case routes.ActionGet:
+		h.expand(c, []interface{}{resp})
+	}
+
+	h.handleSuccessResponse(c, route.Status, "ok", route.Response(resp))
+}
+
+// patchResource applies a JSON merge patch or JSON Patch body. The touched fields
+// must be mutable, backends with an update mask get the mask and the patched fields,
+// others get the current resource with the patch applied
+func (h *handlerV1) patchResource(c *gin.Context, route *routes.Route, id string) {
+	res := route.Resource
+	ctx := c.Request.Context()
+
+	body, err := route.ReadBody(c)
+	if err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	patch, err := routes.ParsePatch(c.ContentType(), body, res.Update.Input())
+	if errors.Is(err, routes.ErrUnsupportedPatch) {
+		h.handleErrorResponse(c, http.StatusUnsupportedMediaType, "unsupported patch format", err.Error())
+		return
+	}
+	if err != nil {
+		h.handleErrorResponse(c, http.StatusBadRequest, "error while parsing patch", err.Error())
+		return
+	}
+
+	mask := patch.Mask()
+	if immutable := res.Immutable(mask.GetPaths()); len(immutable) > 0 {
+		h.handleErrorResponse(c, http.StatusUnprocessableEntity, "fields are not mutable", immutable)
+		return
+	}
+
+	// backends with an update mask get a merge patch as it is, the current resource is only read for If-Match
+	var current proto.Message
+	if res.MaskField == nil || !patch.IsMerge() || c.GetHeader(routes.HeaderIfMatch) != "" {
+		var ok bool
+		if current, ok = h.currentResource(c, route, id); !ok {
+			return
+		}
+		if res.MaskField != nil && patch.IsMerge() {
+			current = nil
+		}
+	}
+
+	req := routes.NewMessage(res.Update.Input())
+	if err := patch.Apply(current, req); errors.Is(err, routes.ErrPatchTestFailed) {
+		h.handleErrorResponse(c, http.StatusConflict, "error while applying patch", err.Error())
+		return
+	} else if err != nil {
+		h.handleErrorResponse(c, http.StatusUnprocessableEntity, "error while applying patch", err.Error())
+		return
+	}
+	if err := routes.SetField(req.ProtoReflect(), res.IDField, []string{id}); err != nil {
+		h.handleErrorResponse(c, http.StatusInternalServerError, "error while binding request", err.Error())
+		return
+	}
+	if route.Validation != nil {
+		var paths []string
+		if res.MaskField != nil {
+			paths = mask.GetPaths()
+		}
+		if err := route.Validation.Validate(req, paths); err != nil {
+			failure := validationFailure(err)
+			h.handleErrorResponse(c, failure.Code, failure.Message, failure.Error)
+			return
+		}
+	}
+	if res.MaskField != nil {
+		paths := req.ProtoReflect().Mutable(res.MaskField).Message()
+		list := paths.Mutable(paths.Descriptor().Fields().ByName("paths")).List()
+		list.Truncate(0)
+		for _, path := range mask.GetPaths() {
+			list.Append(protoreflect.ValueOfString(path))
+		}
+	}
+	if !h.checkWrite(c, route, req) {
+		return
+	}
+
+	resp, err := h.invoke(ctx, res.Update, req)
+	if err != nil {
+		h.handleResourceError(c, route, id, err)
+		return
+	}
+
+	h.handleSuccessResponse(c, route.Status, "ok", route.Response(resp))
+}
+
+// getResource fetches the resource by id, a missing resource is errResourceNotFound
+func (h *handlerV1) getResource(ctx context.Context, res *routes.Resource, id string) (proto.Message, error) {
+	req := routes.NewMessage(res.Get.Input())
+	if err := routes.SetField(req.ProtoReflect(), res.IDField, []string{id}); err != nil {
+		return nil, err
+	}
+
+	resp, err := h.invoke(ctx, res.Get, req)
+	if status.Code(err) == codes.NotFound {
+		return nil, errResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if routes.GetField(resp.ProtoReflect(), res.IDField).String() == "" {
+		return nil, errResourceNotFound
+	}
+
+	return resp, nil
+}
+
+// immutableFailure is the error response of an update, req, changing fields of current the
+// resource does not allow to change, nil when it changes none
+func immutableFailure(res *routes.Resource, current, req proto.Message) *models.ResponseModel {
+	if !res.RestrictsUpdates() {
+		return nil
+	}
+
+	changed, err := res.Changed(current, req)
+	if err != nil {
+		return &models.ResponseModel{Code: http.StatusInternalServerError, Message: "error while comparing " + res.Name, Error: err.Error()}
+	}
+	if immutable := res.Immutable(changed); len(immutable) > 0 {
+		return &models.ResponseModel{Code: http.StatusUnprocessableEntity, Message: "fields are not mutable", Error: immutable}
+	}
+
+	return nil
+}
+
+func (h *handlerV1) handleResourceError(c *gin.Context, route *routes.Route, id string, err error) {
+	failure := resourceFailure(route, id, err)
+	h.handleErrorResponse(c, failure.Code, failure.Message, failure.Error)
+}
+
+// resourceFailure is the error response of a failed call of a resource route, 404 when the resource does not exist
+func resourceFailure(route *routes.Route, id string, err error) *models.ResponseModel {
+	if errors.Is(err, errResourceNotFound) || status.Code(err) == codes.NotFound {
+		return &models.ResponseModel{Code: http.StatusNotFound, Message: route.Resource.Name + " not found", Error: id}
+	}
+
+	return &models.ResponseModel{Code: httpStatusFromError(err), Message: "error while calling " + route.Selector, Error: err.Error()}
+}
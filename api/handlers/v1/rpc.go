@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// CallRPC godoc
+// @ID call-rpc
+// @Router /rpc/{service}/{method} [POST]
+// @Summary calls a backend gRPC method
+// @Description converts the body to the request message of any allowed unary method, discovered with server reflection or a descriptor set, and returns the response as protojson or in the format of the Accept header
+// @Tags rpc
+// @Accept json,application/x-protobuf,application/msgpack,application/yaml
+// @Produce json,application/x-protobuf,application/msgpack,application/yaml
+// @Param service path string true "full service name, e.g. company_service.CompanyService"
+// @Param method path string true "method name, e.g. GetById"
+// @Param body body object false "request message"
+// @Success 200 {object} models.ResponseModel{data=object} "desc"
+// @Response 400 {object} models.ResponseModel{error=string} "Bad Request"
+// @Response 403 {object} models.ResponseModel{error=string} "Forbidden"
+// @Response 404 {object} models.ResponseModel{error=string} "Not Found"
+// @Response 406 {object} models.ResponseModel{error=string} "Not Acceptable"
+// @Response 413 {object} models.ResponseModel{error=string} "Request Entity Too Large"
+// @Response 415 {object} models.ResponseModel{error=string} "Unsupported Media Type"
+// @Failure 500 {object} models.ResponseModel{error=string} "Server Error"
+// @Failure 502 {object} models.ResponseModel{error=string} "Bad Gateway"
+// @Failure 503 {object} models.ResponseModel{error=string} "Service Unavailable"
+func (h *handlerV1) CallRPC(c *gin.Context) {
+	service, method := c.Param("service"), c.Param("method")
+
+	if !h.rpcAllowed(service, method) {
+		h.handleErrorResponse(c, http.StatusForbidden, "method is not allowed", service+"/"+method)
+		return
+	}
+
+	md, conn, err := h.resolver.FindMethod(c.Request.Context(), service, method)
+	if errors.Is(err, services.ErrUnknownMethod) {
+		h.handleErrorResponse(c, http.StatusNotFound, "method not found", err.Error())
+		return
+	} else if err != nil {
+		h.handleErrorResponse(c, resolveStatus(err), "error while resolving "+service+"/"+method, err.Error())
+		return
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		h.handleErrorResponse(c, http.StatusBadRequest, "streaming methods are not supported", service+"/"+method)
+		return
+	}
+
+	body, err := routes.ReadBody(c.Request, h.cfg.MaxBodySize)
+	if err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	req := dynamicpb.NewMessage(md.Input())
+	if len(body) > 0 && c.ContentType() == routes.MIMEProtobuf {
+		if err := proto.Unmarshal(body, req); err != nil {
+			h.handleBindError(c, &routes.DecodeError{Reason: "invalid protobuf: " + err.Error()})
+			return
+		}
+	} else if len(body) > 0 {
+		if err := routes.DecodeJSON(body, req, h.cfg.JSONDiscardUnknown); err != nil {
+			h.handleBindError(c, err)
+			return
+		}
+	}
+
+	resp := dynamicpb.NewMessage(md.Output())
+	err = conn.Invoke(c.Request.Context(), fmt.Sprintf("/%s/%s", service, method), req, resp)
+	h.forgetReference(c.Request.Context(), md, req)
+	if err != nil {
+		h.handleErrorResponse(c, httpStatusFromError(err), "error while calling "+service+"/"+method, err.Error())
+		return
+	}
+
+	h.handleSuccessResponse(c, http.StatusOK, "ok", resp)
+}
+
+// rpcAllowed matches service/method against RPC_ALLOWED_METHODS,
+// entries are "*", "package.Service/*" or "package.Service/Method"
+func (h *handlerV1) rpcAllowed(service, method string) bool {
+	for _, allowed := range h.cfg.RPCAllowedMethods {
+		if allowed == "*" || allowed == service+"/*" || allowed == service+"/"+method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveStatus maps an error finding the descriptors of a method: a backend that can not
+// be reached is unavailable, anything else, e.g. a failed reflection request, a bad gateway
+func resolveStatus(err error) int {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusBadGateway
+}
@@ -1,10 +1,15 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/xfirdavs/api_gateway/config"
 	"github.com/xfirdavs/api_gateway/pkg/logger"
+	"github.com/xfirdavs/api_gateway/pkg/metrics"
 	"github.com/xfirdavs/api_gateway/services"
 
 	// @Summary 登录
@@ -18,77 +23,169 @@ import (
 	// @Router /user/person/login [post]
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/swaggo/swag"
 
+	"github.com/xfirdavs/api_gateway/api/compression"
 	_ "github.com/xfirdavs/api_gateway/api/docs"
 	v1 "github.com/xfirdavs/api_gateway/api/handlers/v1"
+	"github.com/xfirdavs/api_gateway/api/routes"
+	"github.com/xfirdavs/api_gateway/api/versioning"
 )
 
+// swaggerInstance is the swag instance serving the generated document merged with the route table
+const swaggerInstance = "api_gateway"
+
 type RouterOptions struct {
 	Log      logger.Logger
 	Cfg      config.Config
 	Services services.ServiceManager
+	API      *routes.API
+	Resolver *services.Resolver
 }
 
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
-func New(opt *RouterOptions) *gin.Engine {
+func New(opt *RouterOptions) (http.Handler, error) {
 	router := gin.New()
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
+	opt.API.Encoder = routes.Encoder{
+		UseJSONNames:    opt.Cfg.JSONFieldNames == config.JSONNamesJSON,
+		EmitUnpopulated: opt.Cfg.JSONEmitUnpopulated,
+		Int64AsString:   opt.Cfg.JSONInt64AsString,
+	}
+
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 	config.AllowCredentials = true
 	config.AllowHeaders = append(config.AllowHeaders, "*")
 
 	router.Use(cors.New(config))
+
+	compressor, err := compression.New(compression.Options{
+		Encodings:    opt.Cfg.CompressionEncodings,
+		MinSize:      opt.Cfg.CompressionMinSize,
+		ContentTypes: opt.Cfg.CompressionContentTypes,
+		GzipLevel:    opt.Cfg.CompressionGzipLevel,
+		BrotliLevel:  opt.Cfg.CompressionBrotliLevel,
+		ZstdLevel:    opt.Cfg.CompressionZstdLevel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("compression: %w", err)
+	}
+	router.Use(compressor.Middleware())
 	// router.Use(MaxAllowed(100))
 
 	handlerV1 := v1.New(&v1.HandlerV1Options{
 		Log:      opt.Log,
 		Cfg:      opt.Cfg,
 		Services: opt.Services,
+		Resolver: opt.Resolver,
+		Encoder:  opt.API.Encoder,
+
+		Resources: opt.API.Resources,
+	})
+
+	router.GET("/config", handlerV1.AdminOnly(), handlerV1.GetConfig)
+	router.GET("/metrics", handlerV1.AdminOnly(), gin.WrapH(metrics.Handler()))
+
+	versions := opt.API.Versions
+	for _, version := range versions.Names() {
+		api := router.Group("/"+version, versions.Middleware(version))
+		api.GET("/ping", handlerV1.Ping)
+		api.POST("/batch", handlerV1.Negotiate(nil), handlerV1.Idempotency(nil), handlerV1.MultiRequest(router, version))
+	}
+
+	middleware := map[string]gin.HandlerFunc{
+		"admin": handlerV1.AdminOnly(),
+	}
+
+	opt.API.SetBodyDefaults(opt.Cfg.MaxBodySize, opt.Cfg.JSONDiscardUnknown)
+	for _, route := range opt.API.Routes {
+		handlers := make([]gin.HandlerFunc, 0, len(route.Middleware)+6)
+		handlers = append(handlers, versions.Middleware(route.Version), handlerV1.Negotiate(route), handlerV1.CacheControl())
+		for _, name := range route.Middleware {
+			m, ok := middleware[name]
+			if !ok {
+				return nil, fmt.Errorf("route %s: unknown middleware %q", route.Selector, name)
+			}
+			handlers = append(handlers, m)
+		}
+		if route.Method == http.MethodPost || route.Method == http.MethodPatch {
+			handlers = append(handlers, handlerV1.Idempotency(route))
+		}
+		if route.Validation != nil {
+			handlers = append(handlers, handlerV1.Validate(route))
+		}
+		handlers = append(handlers, handlerV1.Proxy(route))
+
+		router.Handle(route.Method, route.GinPath(), handlers...)
+	}
+	for _, batch := range opt.API.Batches {
+		handlers := []gin.HandlerFunc{versions.Middleware(batch.Version), handlerV1.Negotiate(nil), handlerV1.CacheControl()}
+		for _, name := range batch.Middleware() {
+			m, ok := middleware[name]
+			if !ok {
+				return nil, fmt.Errorf("batch %s: unknown middleware %q", batch.Path, name)
+			}
+			handlers = append(handlers, m)
+		}
+		handlers = append(handlers, handlerV1.Idempotency(nil), handlerV1.Batch(batch))
+
+		router.POST(batch.Path, handlers...)
+	}
+
+	if opt.Cfg.RPCProxyEnabled {
+		router.POST("/rpc/:service/:method", handlerV1.Negotiate(nil), handlerV1.CacheControl(), handlerV1.Idempotency(nil), handlerV1.CallRPC)
+	}
+
+	router.NoRoute(versioning.NotFound)
+
+	// swagger, /swagger/index.html documents every version, /swagger/{version}/index.html one version
+	swagger, err := swaggerHandlers(opt.API)
+	if err != nil {
+		return nil, err
+	}
+	router.GET("/swagger/*any", func(c *gin.Context) {
+		version := strings.SplitN(strings.TrimPrefix(c.Param("any"), "/"), "/", 2)[0]
+		if handler, ok := swagger[version]; ok {
+			handler(c)
+			return
+		}
+		swagger[""](c)
 	})
 
-	router.GET("/config", handlerV1.GetConfig)
-
-	apiV1 := router.Group("/v1")
-	apiV1.GET("/ping", handlerV1.Ping)
-
-	// profession
-	apiV1.POST("/profession", handlerV1.CreateProfession)
-	apiV1.GET("/profession", handlerV1.GetAllProfession)
-	apiV1.GET("/profession/:id", handlerV1.GetByIdProfession)
-	apiV1.PUT("/profession", handlerV1.UpdateProfession)
-	apiV1.DELETE("/profession", handlerV1.DeleteProfession)
-
-	// company
-	apiV1.POST("/company", handlerV1.CreateCompany)
-	apiV1.GET("/company", handlerV1.GetAllCompany)
-	apiV1.GET("/company/:id", handlerV1.GetByIdCompany)
-	apiV1.PUT("/company", handlerV1.UpdateCompany)
-	apiV1.DELETE("/company", handlerV1.DeleteCompany)
-
-	// attribute
-	apiV1.POST("/attribute", handlerV1.CreateAttribute)
-	apiV1.GET("/attribute", handlerV1.GetAllAttribute)
-	apiV1.GET("/attribute/:id", handlerV1.GetByIdattribute)
-	apiV1.PUT("/attribute", handlerV1.Updateattribute)
-	apiV1.DELETE("/attribute", handlerV1.Deleteattribute)
-
-	// position
-	apiV1.POST("/position", handlerV1.CreatePosition)
-	apiV1.GET("/position", handlerV1.GetAllPosition)
-	apiV1.GET("/position/:id", handlerV1.GetByIdposition)
-	apiV1.PUT("/position", handlerV1.Updateposition)
-	apiV1.DELETE("/position", handlerV1.Deleteposition)
-
-	// swagger
-	url := ginSwagger.URL("swagger/doc.json") // The url pointing to API definition
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
-
-	return router
+	// requests without version prefix
+	return versions.Handler(router), nil
+}
+
+// swaggerHandlers registers the swagger document of every version and of all
+// versions together, returning their handlers by version name
+func swaggerHandlers(api *routes.API) (map[string]gin.HandlerFunc, error) {
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		return nil, err
+	}
+
+	handlers := map[string]gin.HandlerFunc{}
+	for _, version := range append([]string{""}, api.Versions.Names()...) {
+		swaggerDoc, err := routes.Swagger(doc, api, version)
+		if err != nil {
+			return nil, err
+		}
+
+		instance, url := swaggerInstance, ginSwagger.URL("swagger/doc.json") // The url pointing to API definition
+		if version != "" {
+			instance, url = swaggerInstance+"_"+version, ginSwagger.URL("doc.json")
+		}
+		swag.Register(instance, swaggerDoc)
+
+		handlers[version] = ginSwagger.WrapHandler(swaggerFiles.Handler, url, ginSwagger.InstanceName(instance))
+	}
+
+	return handlers, nil
 }
 
 func MaxAllowed(n int) gin.HandlerFunc {
@@ -0,0 +1,55 @@
+package models
+
+import "encoding/json"
+
+// BatchRequest is the body of POST /{version}/{resource}/batch, Mode is routes.BatchBestEffort
+// by default or routes.BatchAllOrNothing
+type BatchRequest struct {
+	Mode       string           `json:"mode"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation creates, updates or deletes one resource. Body is the body of the
+// create or update route, ID the id of the resource updated or deleted.
+type BatchOperation struct {
+	Action string          `json:"action"`
+	ID     string          `json:"id,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchResult is the outcome of an operation, with the status, message, error and data
+// the route of its action would have answered
+type BatchResult struct {
+	Index   int         `json:"index"`
+	Action  string      `json:"action"`
+	ID      string      `json:"id,omitempty"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Error   interface{} `json:"error"`
+	Data    interface{} `json:"data"`
+}
+
+// MultiRequest is the body of POST /{version}/batch, its requests are served by the gateway
+// routes as if they were sent one by one
+type MultiRequest struct {
+	Requests []SubRequest `json:"requests"`
+}
+
+// SubRequest is a request of a MultiRequest. Path, header values and body strings may
+// reference the response of an earlier request by its ID, e.g. /v2/company/${company.data.id}.
+type SubRequest struct {
+	ID      string            `json:"id,omitempty"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty" swaggertype:"object"`
+}
+
+// SubResponse is the response to a SubRequest
+type SubResponse struct {
+	Index   int               `json:"index"`
+	ID      string            `json:"id,omitempty"`
+	Code    int               `json:"code"`
+	Headers map[string]string `json:"headers"`
+	Body    interface{}       `json:"body"`
+}
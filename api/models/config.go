@@ -0,0 +1,12 @@
+package models
+
+import "github.com/xfirdavs/api_gateway/config"
+
+// ConfigResponse ...
+type ConfigResponse struct {
+	Environment string         `json:"environment"`
+	Version     string         `json:"version"`
+	GitCommit   string         `json:"git_commit"`
+	BuildTime   string         `json:"build_time"`
+	Values      []config.Value `json:"values"`
+}
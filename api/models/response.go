@@ -6,4 +6,14 @@ type ResponseModel struct {
 	Message string      `json:"message"`
 	Error   interface{} `json:"error"`
 	Data    interface{} `json:"data"`
+	Meta    *PageMeta   `json:"meta,omitempty"`
+}
+
+// PageMeta describes the page of a list response
+type PageMeta struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"sort"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// annotatedRules returns a rule for every google.api.http binding, including
+// additional_bindings, of the methods registered in files
+func annotatedRules(files *protoregistry.Files) []Rule {
+	var rules []Rule
+
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				md := methods.Get(j)
+				if md.Options() == nil || !proto.HasExtension(md.Options(), annotations.E_Http) {
+					continue
+				}
+
+				http := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+				rules = append(rules, ruleFromHttp(string(md.FullName()), http))
+				for _, binding := range http.GetAdditionalBindings() {
+					rules = append(rules, ruleFromHttp(string(md.FullName()), binding))
+				}
+			}
+		}
+		return true
+	})
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Selector < rules[j].Selector
+	})
+
+	return rules
+}
+
+func ruleFromHttp(selector string, http *annotations.HttpRule) Rule {
+	rule := Rule{
+		Selector:     selector,
+		Body:         http.GetBody(),
+		ResponseBody: http.GetResponseBody(),
+	}
+
+	switch pattern := http.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		rule.Get = pattern.Get
+	case *annotations.HttpRule_Put:
+		rule.Put = pattern.Put
+	case *annotations.HttpRule_Post:
+		rule.Post = pattern.Post
+	case *annotations.HttpRule_Delete:
+		rule.Delete = pattern.Delete
+	case *annotations.HttpRule_Patch:
+		rule.Patch = pattern.Patch
+	case *annotations.HttpRule_Custom:
+		rule.Custom = &CustomPattern{Kind: pattern.Custom.GetKind(), Path: pattern.Custom.GetPath()}
+	}
+
+	return rule
+}
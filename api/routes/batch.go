@@ -0,0 +1,205 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-openapi/spec"
+	"github.com/xfirdavs/api_gateway/pkg/idempotency"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// batchSegment is the last path segment of the batch route of a resource
+const batchSegment = "batch"
+
+// Modes of a batch request
+const (
+	// BatchBestEffort runs every valid operation, whatever happens to the others
+	BatchBestEffort = "best_effort"
+	// BatchAllOrNothing runs the operations only when all are valid and undoes the
+	// creates and updates done when one fails
+	BatchAllOrNothing = "all_or_nothing"
+)
+
+// Batch is the route running several creates, updates and deletes of a resource in one
+// request: POST /{version}/{name}/batch in every version calling the Create, Update or Delete
+// method of the resource with POST, PUT or DELETE, v1 included.
+//
+// The body is {mode, operations: [{action, id, body}]} with at most BATCH_MAX_ITEMS operations,
+// run BATCH_CONCURRENCY at a time. The batch body is limited by MAX_BODY_SIZE, the body of every
+// operation by the MaxBodySize of its route. The batch answers 207 with the code, message, error
+// and data of every operation in the order sent. In BatchAllOrNothing mode the deletes run last
+// and, when an operation fails, the resources created are deleted and the updated ones put back,
+// answered 424 "undone"; deletes that ran are not undone.
+type Batch struct {
+	Resource *Resource
+	Version  string
+	Path     string
+
+	// Routes are the create, update and delete routes of the resource in Version by action,
+	// the operations of the batch are bound, checked and answered as these routes do
+	Routes map[string]*Route
+}
+
+// Middleware returns the named middleware of the routes of the batch, each once. The batch
+// route runs all of them, so an operation is never served without the middleware of its route.
+func (b *Batch) Middleware() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, action := range []string{ActionCreate, ActionUpdate, ActionDelete} {
+		route, ok := b.Routes[action]
+		if !ok {
+			continue
+		}
+		for _, name := range route.Middleware {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+// batches returns the batch route of every resource and version served by routes. Routes
+// calling the Create, Update or Delete method of a resource with POST, PUT or DELETE outside
+// its RESTful routes, e.g. the annotated /v1 routes, serve the operations of their version.
+func batches(routes []*Route, resources []*Resource) []*Batch {
+	var list []*Batch
+	byPath := map[string]*Batch{}
+	for _, route := range routes {
+		route = batchRoute(route, resources)
+		if route == nil {
+			continue
+		}
+
+		path := "/" + route.Version + "/" + route.Resource.Name + "/" + batchSegment
+		b, ok := byPath[path]
+		if !ok {
+			b = &Batch{Resource: route.Resource, Version: route.Version, Path: path, Routes: map[string]*Route{}}
+			byPath[path] = b
+			list = append(list, b)
+		}
+		if _, ok := b.Routes[route.Action]; !ok {
+			b.Routes[route.Action] = route
+		}
+	}
+
+	return list
+}
+
+// batchRoute returns the route with the resource and action of the batch operation it
+// serves, nil when it serves none
+func batchRoute(route *Route, resources []*Resource) *Route {
+	switch route.Action {
+	case ActionCreate, ActionUpdate, ActionDelete:
+		return route
+	case "":
+	default:
+		return nil
+	}
+
+	for _, res := range resources {
+		var action string
+		switch {
+		case route.RPC == res.Create && route.Method == http.MethodPost:
+			action = ActionCreate
+		case route.RPC == res.Update && route.Method == http.MethodPut:
+			action = ActionUpdate
+		case route.RPC == res.Delete && route.Method == http.MethodDelete:
+			action = ActionDelete
+		default:
+			continue
+		}
+
+		r := *route
+		r.Resource, r.Action = res, action
+		return &r
+	}
+
+	return nil
+}
+
+// RestoreRequest returns the Update request putting the resource back to current, a response
+// of its Get method. With an update mask every field but the id and the mask is in the mask.
+func (res *Resource) RestoreRequest(current proto.Message) (proto.Message, error) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	req := NewMessage(res.Update.Input())
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, req); err != nil {
+		return nil, err
+	}
+
+	if res.MaskField != nil {
+		msg := req.ProtoReflect()
+		mask := msg.Mutable(res.MaskField).Message()
+		paths := mask.Mutable(mask.Descriptor().Fields().ByName("paths")).List()
+		fields := msg.Descriptor().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			if name := string(fields.Get(i).Name()); name != res.IDField && fields.Get(i) != res.MaskField {
+				paths.Append(protoreflect.ValueOfString(name))
+			}
+		}
+	}
+
+	return req, nil
+}
+
+func (b *Batch) operation(defs spec.Definitions) *spec.Operation {
+	res := b.Resource
+	addDefinition(defs, res.Create.Input())
+	addDefinition(defs, res.Update.Input())
+
+	operation := new(spec.Schema).Typed("object", "").
+		SetProperty("action", *spec.StringProperty().WithEnum(ActionCreate, ActionUpdate, ActionDelete)).
+		SetProperty("id", *spec.StrFmtProperty(FormatUUID).WithDescription("id of the " + res.Name + " updated or deleted")).
+		SetProperty("body", *new(spec.Schema).Typed("object", "").
+			WithDescription("the body of the create route, a " + string(res.Create.Input().FullName()) +
+				", or of the update route, a " + string(res.Update.Input().FullName())))
+	operation.WithRequired("action")
+	body := new(spec.Schema).Typed("object", "").
+		SetProperty("mode", *spec.StringProperty().WithEnum(BatchBestEffort, BatchAllOrNothing).WithDefault(BatchBestEffort)).
+		SetProperty("operations", *spec.ArrayProperty(operation))
+	body.WithRequired("operations")
+
+	result := new(spec.Schema).Typed("object", "").
+		SetProperty("index", *spec.Int64Property()).
+		SetProperty("action", *spec.StringProperty()).
+		SetProperty("id", *spec.StringProperty()).
+		SetProperty("code", *spec.Int64Property()).
+		SetProperty("message", *spec.StringProperty()).
+		SetProperty("error", *new(spec.Schema)).
+		SetProperty("data", *spec.RefSchema(addDefinition(defs, res.Get.Output())))
+
+	tag := res.Name
+	if route, ok := b.Routes[ActionCreate]; ok {
+		tag = route.Tag
+	}
+	op := spec.NewOperation("batch_"+res.Name).
+		WithSummary("creates, updates and deletes several "+res.Name).
+		WithDescription("Runs the operations concurrently, each bound, checked and answered as its route would. "+
+			"best_effort runs every valid operation; all_or_nothing runs none unless all are valid and, when one "+
+			"fails, deletes the created "+res.Name+" and restores the updated ones. Deletes run last and are not undone.").
+		WithTags(tag).
+		WithConsumes("application/json", MIMEMsgPack, MIMEYAML).
+		WithProduces("application/json", MIMEMsgPack, MIMEYAML)
+	op.AddParam(spec.BodyParam("body", body).AsRequired())
+	op.AddParam(spec.HeaderParam(idempotency.Header).Typed("string", "").
+		WithDescription(fmt.Sprintf("unique key of the request, at most %d characters: repeats get the stored response with %s: true", idempotency.MaxKeyLength, idempotency.ReplayedHeader)))
+
+	op.RespondsWith(http.StatusMultiStatus, wrapped("Multi-Status, the result of every operation in the order sent", "data", *spec.ArrayProperty(result)))
+	op.RespondsWith(http.StatusBadRequest, wrapped("Bad Request, the body is not a batch", "error", *spec.StringProperty()))
+	op.RespondsWith(http.StatusNotAcceptable, wrapped("Not Acceptable, the Accept header names no supported format", "error", *spec.StringProperty()))
+	op.RespondsWith(http.StatusConflict, wrapped("Conflict, the Idempotency-Key was sent with another request", "error", *spec.StringProperty()))
+	op.RespondsWith(http.StatusRequestEntityTooLarge, wrapped("Request Entity Too Large", "error", *spec.StringProperty()))
+	op.RespondsWith(http.StatusUnsupportedMediaType, wrapped("Unsupported Media Type", "error", *spec.StringProperty()))
+	op.RespondsWith(http.StatusTooEarly, wrapped("Too Early, the first request with the Idempotency-Key is in flight", "error", *spec.StringProperty()))
+	op.RespondsWith(http.StatusInternalServerError, wrapped("Server Error", "error", *spec.StringProperty()))
+
+	return op
+}
@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"reflect"
+	"testing"
+
+	_ "github.com/xfirdavs/api_gateway/genproto/company_service"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func TestBatchMiddleware(t *testing.T) {
+	table := []byte(`
+versions:
+  - name: v1
+  - name: v2
+default_version: v1
+routes:
+  - selector: company_service.CompanyService.Update
+    middleware: [admin]
+  - selector: company_service.CompanyService.Delete
+    middleware: [admin, audit]
+resources:
+  - name: company
+    service: company_service.CompanyService
+`)
+	api, err := Parse(table, protoregistry.GlobalFiles, protoregistry.GlobalTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		// the annotated v1 routes carry the middleware of the rules
+		"/v1/company/batch": {"admin", "audit"},
+		// the RESTful routes of the resource do not
+		"/v2/company/batch": nil,
+	}
+	for _, b := range api.Batches {
+		names, ok := want[b.Path]
+		if !ok {
+			continue
+		}
+		delete(want, b.Path)
+		if got := b.Middleware(); !reflect.DeepEqual(got, names) {
+			t.Errorf("%s: middleware %v, want %v", b.Path, got, names)
+		}
+	}
+	for path := range want {
+		t.Errorf("no batch route %s", path)
+	}
+}
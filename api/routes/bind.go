@@ -0,0 +1,243 @@
+package routes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Bind builds the gRPC request message from the route defaults, the request body,
+// the path variables and the query string, in that order.
+// Query parameters are applied last so that clients of the original handlers,
+// which sent the id as ?id= on /v1/{resource}/:id, keep working. Routes with
+// filters only accept their filters, see Filter; others ignore unknown parameters.
+// The limit of a route with MaxPageSize must lie between 1 and MaxPageSize.
+func (r *Route) Bind(c *gin.Context) (proto.Message, error) {
+	msg := r.Input.New()
+
+	for field, value := range r.Defaults {
+		if err := SetField(msg, field, []string{value}); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Body != "" {
+		if err := r.bindBody(c, msg); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range r.template.segments {
+		if s.field == "" {
+			continue
+		}
+		value := c.Param(s.param())
+		if s.wildcard {
+			value = strings.TrimPrefix(value, "/")
+		}
+		if err := SetField(msg, s.field, []string{value}); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Filters != nil {
+		if err := r.bindFilters(msg, c.Request.URL.Query()); err != nil {
+			return nil, err
+		}
+	} else if r.Body != "*" {
+		for key, values := range c.Request.URL.Query() {
+			if _, err := findField(msg.Descriptor(), key); err != nil || r.HasParam(key) {
+				continue
+			}
+			if err := SetField(msg, key, values); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if r.MaxPageSize > 0 {
+		if limit := GetField(msg, "limit").Int(); limit < 1 || limit > int64(r.MaxPageSize) {
+			return nil, FilterError{fmt.Sprintf("limit must be between 1 and %d", r.MaxPageSize)}
+		}
+	}
+
+	return msg.Interface(), nil
+}
+
+// HasParam reports whether the gateway handles the query parameter key itself
+func (r *Route) HasParam(key string) bool {
+	for _, param := range r.Params {
+		if param == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Route) bindBody(c *gin.Context, msg protoreflect.Message) error {
+	data, err := r.ReadBody(c)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	target := msg
+	if r.Body != "*" {
+		fds, err := findField(msg.Descriptor(), r.Body)
+		if err != nil {
+			return err
+		}
+		for _, fd := range fds {
+			if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return fmt.Errorf("body field %q must be a message", r.Body)
+			}
+			target = target.Mutable(fd).Message()
+		}
+	}
+
+	// protojson resets the message it decodes into, so decode separately and merge
+	// to keep the defaults that are already set
+	body := target.New().Interface()
+	if err := r.decode(data, body); err != nil {
+		return err
+	}
+	proto.Merge(target.Interface(), body)
+
+	return nil
+}
+
+// Response returns the part of the response message selected by response_body
+func (r *Route) Response(m proto.Message) interface{} {
+	if r.ResponseBody == "" {
+		return m
+	}
+
+	msg := m.ProtoReflect()
+	fds, _ := findField(msg.Descriptor(), r.ResponseBody)
+	for _, fd := range fds[:len(fds)-1] {
+		msg = msg.Get(fd).Message()
+	}
+
+	fd := fds[len(fds)-1]
+	v := msg.Get(fd)
+	switch {
+	case fd.IsList():
+		list := v.List()
+		items := make([]interface{}, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			items = append(items, valueInterface(fd, list.Get(i)))
+		}
+		return items
+	case fd.IsMap():
+		return v.Map()
+	default:
+		return valueInterface(fd, v)
+	}
+}
+
+func valueInterface(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	if fd.Message() != nil {
+		return v.Message().Interface()
+	}
+
+	return v.Interface()
+}
+
+// GetField returns the value of the field at the dotted path, or an invalid value when the path is unknown
+func GetField(msg protoreflect.Message, path string) protoreflect.Value {
+	fds, err := findField(msg.Descriptor(), path)
+	if err != nil {
+		return protoreflect.Value{}
+	}
+
+	for _, fd := range fds[:len(fds)-1] {
+		msg = msg.Get(fd).Message()
+	}
+
+	return msg.Get(fds[len(fds)-1])
+}
+
+// SetField parses values into the field at the dotted path, repeated fields get every value
+func SetField(msg protoreflect.Message, path string, values []string) error {
+	fds, err := findField(msg.Descriptor(), path)
+	if err != nil {
+		return err
+	}
+
+	for _, fd := range fds[:len(fds)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+
+	fd := fds[len(fds)-1]
+	switch {
+	case fd.IsMap():
+		return fmt.Errorf("field %q: map fields can not be set from a string", path)
+	case fd.IsList():
+		list := msg.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseValue(fd, s)
+			if err != nil {
+				return fmt.Errorf("field %q: %w", path, err)
+			}
+			list.Append(v)
+		}
+	case len(values) > 0:
+		v, err := parseValue(fd, values[len(values)-1])
+		if err != nil {
+			return fmt.Errorf("field %q: %w", path, err)
+		}
+		msg.Set(fd, v)
+	}
+
+	return nil
+}
+
+func parseValue(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.BytesKind:
+		v, err := base64.StdEncoding.DecodeString(s)
+		return protoreflect.ValueOfBytes(v), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		v, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown enum value %q", s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), nil
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/xfirdavs/api_gateway/genproto/company_service"
+)
+
+func TestBindMaxPageSize(t *testing.T) {
+	api, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var route *Route
+	for _, r := range api.Routes {
+		if r.Method == http.MethodGet && r.Path == "/v1/company" {
+			route = r
+		}
+	}
+	if route == nil || route.MaxPageSize != defaultMaxPageSize {
+		t.Fatalf("GET /v1/company is not bounded: %+v", route)
+	}
+
+	tests := []struct {
+		query string
+		ok    bool
+	}{
+		{query: "", ok: true},
+		{query: "limit=1", ok: true},
+		{query: "limit=100", ok: true},
+		{query: "limit=0"},
+		{query: "limit=-1"},
+		{query: "limit=2000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/v1/company?"+tt.query, nil)
+
+			_, err := route.Bind(c)
+			var filterErr FilterError
+			switch {
+			case tt.ok && err != nil:
+				t.Errorf("Bind: %v", err)
+			case !tt.ok && !errors.As(err, &filterErr):
+				t.Errorf("Bind error %v, want a FilterError", err)
+			}
+		})
+	}
+}
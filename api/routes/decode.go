@@ -0,0 +1,208 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// defaultMaxBodySize limits request bodies when neither the rule nor the config set a limit
+const defaultMaxBodySize = 1 << 20
+
+// ErrBodyTooLarge is returned for a request body over the MaxBodySize of the route
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// DecodeError is a request body that can not be decoded into the request message,
+// Path is the JSON path of the offending value, e.g. position_attributes[1].value
+type DecodeError struct {
+	Path   string `json:"path,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (e *DecodeError) Error() string {
+	if e.Path == "" {
+		return e.Reason
+	}
+
+	return e.Path + ": " + e.Reason
+}
+
+// ReadBody reads the request body, at most MaxBodySize bytes
+func (r *Route) ReadBody(c *gin.Context) ([]byte, error) {
+	return ReadBody(c.Request, r.MaxBodySize)
+}
+
+// ReadBody reads the body of req, ErrBodyTooLarge when it has more than max bytes
+func ReadBody(req *http.Request, max int64) ([]byte, error) {
+	if req.ContentLength > max {
+		return nil, ErrBodyTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, ErrBodyTooLarge
+	}
+
+	return data, nil
+}
+
+func (r *Route) decode(data []byte, msg proto.Message) error {
+	return DecodeJSON(data, msg, r.DiscardUnknown)
+}
+
+// DecodeJSON decodes data into msg with protojson. Proto and JSON (camelCase) field names
+// are accepted, unknown fields are rejected unless discardUnknown is set. Errors are
+// *DecodeError with the JSON path of the offending value.
+func DecodeJSON(data []byte, msg proto.Message, discardUnknown bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return &DecodeError{Reason: "invalid JSON: " + err.Error()}
+	}
+	if dec.More() {
+		return &DecodeError{Reason: "invalid JSON: data after the top-level value"}
+	}
+	if err := checkJSON(msg.ProtoReflect().Descriptor(), doc, "", discardUnknown); err != nil {
+		return err
+	}
+
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: discardUnknown}).Unmarshal(data, msg); err != nil {
+		return &DecodeError{Reason: strings.TrimPrefix(err.Error(), "proto: ")}
+	}
+
+	return nil
+}
+
+// checkJSON finds the first value of doc that does not fit md, the protojson errors
+// only tell the line and column
+func checkJSON(md protoreflect.MessageDescriptor, doc interface{}, path string, discardUnknown bool) *DecodeError {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return &DecodeError{Path: path, Reason: "must be an object"}
+	}
+
+	for key, value := range obj {
+		fd := md.Fields().ByName(protoreflect.Name(key))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(key)
+		}
+		fieldPath := joinPath(path, key)
+		if fd == nil {
+			if discardUnknown {
+				continue
+			}
+			return &DecodeError{Path: fieldPath, Reason: "unknown field"}
+		}
+		if value == nil {
+			continue
+		}
+
+		switch {
+		case fd.IsList():
+			items, ok := value.([]interface{})
+			if !ok {
+				return &DecodeError{Path: fieldPath, Reason: "must be an array"}
+			}
+			for i, item := range items {
+				if err := checkValue(fd, item, fmt.Sprintf("%s[%d]", fieldPath, i), discardUnknown); err != nil {
+					return err
+				}
+			}
+		case fd.IsMap():
+			entries, ok := value.(map[string]interface{})
+			if !ok {
+				return &DecodeError{Path: fieldPath, Reason: "must be an object"}
+			}
+			for k, item := range entries {
+				if err := checkValue(fd.MapValue(), item, fmt.Sprintf("%s[%s]", fieldPath, k), discardUnknown); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := checkValue(fd, value, fieldPath, discardUnknown); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkValue(fd protoreflect.FieldDescriptor, value interface{}, path string, discardUnknown bool) *DecodeError {
+	if value == nil {
+		return nil
+	}
+
+	if md := fd.Message(); md != nil {
+		if strings.HasPrefix(string(md.FullName()), "google.protobuf.") {
+			return nil // well-known types have JSON encodings of their own, protojson checks them
+		}
+		return checkJSON(md, value, path, discardUnknown)
+	}
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		if _, ok := value.(string); !ok {
+			return &DecodeError{Path: path, Reason: "must be a string"}
+		}
+	case protoreflect.BytesKind:
+		if _, ok := value.(string); !ok {
+			return &DecodeError{Path: path, Reason: "must be a base64 string"}
+		}
+	case protoreflect.BoolKind:
+		if _, ok := value.(bool); !ok {
+			return &DecodeError{Path: path, Reason: "must be a boolean"}
+		}
+	case protoreflect.EnumKind:
+		switch v := value.(type) {
+		case string:
+			if fd.Enum().Values().ByName(protoreflect.Name(v)) == nil {
+				return &DecodeError{Path: path, Reason: fmt.Sprintf("unknown %s value %q", fd.Enum().Name(), v)}
+			}
+		case json.Number:
+		default:
+			return &DecodeError{Path: path, Reason: "must be an enum name or number"}
+		}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		if _, ok := value.(json.Number); !ok {
+			if _, ok := value.(string); !ok {
+				return &DecodeError{Path: path, Reason: "must be a number"}
+			}
+		}
+	default:
+		switch v := value.(type) {
+		case json.Number:
+			if f, err := v.Float64(); err != nil || f != math.Trunc(f) {
+				return &DecodeError{Path: path, Reason: "must be an integer"}
+			}
+		case string:
+		default:
+			return &DecodeError{Path: path, Reason: "must be an integer"}
+		}
+	}
+
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
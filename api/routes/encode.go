@@ -0,0 +1,210 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-openapi/spec"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Encoder writes the messages of responses as JSON with protojson, so that the encoding
+// follows the proto definitions instead of the struct tags of the generated code. It is
+// set by JSON_FIELD_NAMES, JSON_EMIT_UNPOPULATED and JSON_INT64_AS_STRING.
+type Encoder struct {
+	// UseJSONNames writes lowerCamelCase names instead of the proto field names
+	UseJSONNames bool
+	// EmitUnpopulated writes fields with zero values, lists and maps as [] and {}
+	EmitUnpopulated bool
+	// Int64AsString writes 64-bit integers as strings, as the proto3 JSON mapping does,
+	// instead of numbers
+	Int64AsString bool
+}
+
+// EncodeData encodes the messages of data, a message or a list, pruned to selection when it is
+// not nil. The result is the decoded JSON document, other data is returned unchanged.
+func (e Encoder) EncodeData(data interface{}, selection *FieldSelection) (interface{}, error) {
+	switch d := data.(type) {
+	case proto.Message:
+		return e.Encode(d, selection)
+	case []interface{}:
+		items := make([]interface{}, 0, len(d))
+		for _, item := range d {
+			m, ok := item.(proto.Message)
+			if !ok {
+				if selection != nil {
+					return nil, fmt.Errorf("fields can only be selected in messages")
+				}
+				items = append(items, item)
+				continue
+			}
+			encoded, err := e.Encode(m, selection)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, encoded)
+		}
+		return items, nil
+	}
+
+	if selection != nil {
+		return nil, fmt.Errorf("fields can only be selected in messages")
+	}
+
+	return data, nil
+}
+
+// Encode returns the JSON document of m, with only the fields of selection when it is not nil
+func (e Encoder) Encode(m proto.Message, selection *FieldSelection) (interface{}, error) {
+	if selection != nil {
+		m = proto.Clone(m)
+		selection.Prune(m.ProtoReflect())
+	}
+
+	// with a selection unpopulated fields are emitted so that every selected field is present,
+	// the unselected ones are then dropped from the document
+	data, err := protojson.MarshalOptions{
+		UseProtoNames:   !e.UseJSONNames,
+		EmitUnpopulated: e.EmitUnpopulated || selection != nil,
+	}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	if selection != nil {
+		selection.filter(doc, m.ProtoReflect().Descriptor())
+	}
+	if !e.Int64AsString {
+		int64Numbers(doc, m.ProtoReflect().Descriptor())
+	}
+
+	return doc, nil
+}
+
+// int64Numbers turns the 64-bit integers protojson wrote as strings in doc, the JSON of a message of md, into numbers
+func int64Numbers(doc interface{}, md protoreflect.MessageDescriptor) {
+	obj, ok := doc.(map[string]interface{})
+	if !ok || md == nil {
+		return
+	}
+
+	for key, value := range obj {
+		fd := jsonField(md, key)
+		if fd == nil {
+			continue
+		}
+		if fd.IsMap() {
+			fd = fd.MapValue()
+		}
+
+		switch v := value.(type) {
+		case string:
+			if is64Bit(fd) {
+				obj[key] = json.Number(v)
+			}
+		case []interface{}:
+			for i, item := range v {
+				if s, ok := item.(string); ok && is64Bit(fd) {
+					v[i] = json.Number(s)
+				} else {
+					int64Numbers(item, elementMessage(fd))
+				}
+			}
+		case map[string]interface{}:
+			if !jsonField(md, key).IsMap() {
+				int64Numbers(v, elementMessage(fd))
+				continue
+			}
+			for k, item := range v {
+				if s, ok := item.(string); ok && is64Bit(fd) {
+					v[k] = json.Number(s)
+				} else {
+					int64Numbers(item, elementMessage(fd))
+				}
+			}
+		}
+	}
+}
+
+func is64Bit(fd protoreflect.FieldDescriptor) bool {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return true
+	}
+
+	return false
+}
+
+// jsonField returns the field of md written as key, a proto or JSON name
+func jsonField(md protoreflect.MessageDescriptor, key string) protoreflect.FieldDescriptor {
+	if fd := md.Fields().ByName(protoreflect.Name(key)); fd != nil {
+		return fd
+	}
+
+	return md.Fields().ByJSONName(key)
+}
+
+// document renames the properties of the message schemas in defs to the JSON names and
+// types the encoder writes
+func (e Encoder) document(defs spec.Definitions) {
+	for name, schema := range defs {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			continue
+		}
+		md, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			continue
+		}
+
+		properties := make(spec.SchemaProperties, len(schema.Properties))
+		for key, property := range schema.Properties {
+			fd := md.Fields().ByName(protoreflect.Name(key))
+			if fd != nil && e.UseJSONNames {
+				key = fd.JSONName()
+			}
+			if fd != nil && e.Int64AsString {
+				switch {
+				case fd.IsMap() && is64Bit(fd.MapValue()):
+					stringInt64(property.AdditionalProperties.Schema)
+				case fd.IsList() && is64Bit(fd):
+					stringInt64(property.Items.Schema)
+				case is64Bit(fd):
+					stringInt64(&property)
+				}
+			}
+			properties[key] = property
+		}
+		schema.Properties = properties
+
+		if e.UseJSONNames {
+			for i, required := range schema.Required {
+				if fd := md.Fields().ByName(protoreflect.Name(required)); fd != nil {
+					schema.Required[i] = fd.JSONName()
+				}
+			}
+		}
+		defs[name] = schema
+	}
+}
+
+func stringInt64(s *spec.Schema) {
+	if s == nil || !s.Type.Contains("integer") {
+		return
+	}
+
+	s.Type = spec.StringOrArray{"string"}
+	s.Format = "int64"
+}
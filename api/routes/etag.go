@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"strings"
+
+	"github.com/xfirdavs/api_gateway/api/compression"
+	"google.golang.org/protobuf/proto"
+)
+
+// Headers of conditional requests: GET responses carry the ETag of their data, If-None-Match
+// answers 304 when it did not change and If-Match guards updates and deletes with 412.
+// Last-Modified and If-Modified-Since are not supported, the resources have no modification time.
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// ETagHash computes the strong entity tag of a response from its media type, the deterministic
+// proto encoding of its messages and the JSON encoding of its other values
+type ETagHash struct {
+	h hash.Hash
+}
+
+// NewETagHash returns the hash of a representation in mediaType, the representations of a
+// resource in different formats have different tags
+func NewETagHash(mediaType string) *ETagHash {
+	e := &ETagHash{h: sha256.New()}
+	e.write([]byte(MediaType(mediaType)))
+
+	return e
+}
+
+// Message adds m to the hash
+func (e *ETagHash) Message(m proto.Message) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return err
+	}
+	e.write(data)
+
+	return nil
+}
+
+// Value adds v to the hash, a message or a value encoded as JSON
+func (e *ETagHash) Value(v interface{}) error {
+	if m, ok := v.(proto.Message); ok {
+		return e.Message(m)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.write(data)
+
+	return nil
+}
+
+// Sum returns the quoted entity tag
+func (e *ETagHash) Sum() string {
+	return `"` + hex.EncodeToString(e.h.Sum(nil)[:16]) + `"`
+}
+
+// write adds a length prefixed part, so that different parts never hash alike
+func (e *ETagHash) write(data []byte) {
+	var size [binary.MaxVarintLen64]byte
+	e.h.Write(size[:binary.PutUvarint(size[:], uint64(len(data)))])
+	e.h.Write(data)
+}
+
+// MatchETag reports whether tag is listed in an If-Match or If-None-Match header and returns
+// the listed tag that matched; "*" matches any tag. The weak comparison of If-None-Match
+// ignores the W/ prefix, the strong comparison of If-Match never matches a weak tag. The
+// content coding suffix of the tags of compressed responses is ignored.
+func MatchETag(header, tag string, weak bool) (string, bool) {
+	for _, listed := range strings.Split(header, ",") {
+		listed = strings.TrimSpace(listed)
+		if listed == "*" {
+			return tag, true
+		}
+
+		candidate := listed
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if compression.ETagBase(candidate) == tag {
+			return listed, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/xfirdavs/api_gateway/genproto/company_service"
+)
+
+func TestMatchETag(t *testing.T) {
+	const tag = `"abc"`
+
+	tests := []struct {
+		name   string
+		header string
+		weak   bool
+		// matched is the listed tag returned, "" when none matches
+		matched string
+	}{
+		{name: "empty", header: ""},
+		{name: "same", header: `"abc"`, matched: `"abc"`},
+		{name: "other", header: `"def"`},
+		{name: "listed", header: `"def", "abc"`, matched: `"abc"`},
+		{name: "listed without spaces", header: `"def","abc"`, matched: `"abc"`},
+		{name: "any", header: "*", matched: tag},
+		{name: "unquoted", header: "abc"},
+		{name: "weak compared weakly", header: `W/"abc"`, weak: true, matched: `W/"abc"`},
+		{name: "weak compared strongly", header: `W/"abc"`},
+		{name: "compressed", header: `"abc-gzip"`, matched: `"abc-gzip"`},
+		{name: "weak compressed", header: `W/"abc-br"`, weak: true, matched: `W/"abc-br"`},
+		{name: "unknown coding", header: `"abc-deflate"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, ok := MatchETag(tt.header, tag, tt.weak)
+			if ok != (tt.matched != "") || matched != tt.matched {
+				t.Errorf("MatchETag(%s) = %s, %v, want %s", tt.header, matched, ok, tt.matched)
+			}
+		})
+	}
+}
+
+func TestETagHash(t *testing.T) {
+	sum := func(mediaType string, values ...interface{}) string {
+		t.Helper()
+		hash := NewETagHash(mediaType)
+		for _, v := range values {
+			if err := hash.Value(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return hash.Sum()
+	}
+	company := &company_service.Company{Id: "1", Name: "acme"}
+
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{name: "same data", a: sum(binding.MIMEJSON, company), b: sum(binding.MIMEJSON, &company_service.Company{Name: "acme", Id: "1"}), same: true},
+		{name: "other data", a: sum(binding.MIMEJSON, company), b: sum(binding.MIMEJSON, &company_service.Company{Id: "1", Name: "acme2"})},
+		{name: "other format", a: sum(binding.MIMEJSON, company), b: sum(MIMEYAML, company)},
+		{name: "format alias", a: sum(MIMEYAML, company), b: sum(binding.MIMEYAML, company), same: true},
+		{name: "parts are delimited", a: sum(binding.MIMEJSON, "ab", "c"), b: sum(binding.MIMEJSON, "a", "bc")},
+		{name: "meta counts", a: sum(binding.MIMEJSON, company), b: sum(binding.MIMEJSON, company, map[string]int{"count": 1})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.a == tt.b) != tt.same {
+				t.Errorf("tags %s and %s, want equal %v", tt.a, tt.b, tt.same)
+			}
+			if len(tt.a) != 34 || tt.a[0] != '"' || tt.a[33] != '"' {
+				t.Errorf("tag %s is not a quoted strong tag", tt.a)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParamExpand is the query parameter of the resource get and list routes embedding
+// related resources, e.g. expand=company,profession
+const ParamExpand = "expand"
+
+// Expansion embeds the resource referenced by an id field of the items, fetched with its Get method,
+// in the get and list responses requesting it. A resource that can not be fetched is embedded as
+// null and named in missing_expansions.
+type Expansion struct {
+	Name     string `yaml:"name"`
+	Field    string `yaml:"field"`
+	Resource string `yaml:"resource"`
+
+	// Target is the referenced resource
+	Target *Resource `yaml:"-"`
+}
+
+// resolveExpansions links the expansions of every resource to their target resource
+func resolveExpansions(resources []*Resource) error {
+	byName := make(map[string]*Resource, len(resources))
+	for _, res := range resources {
+		byName[res.Name] = res
+	}
+
+	for _, res := range resources {
+		fds, _ := findField(res.List.Output(), res.ItemsField)
+		item := fds[len(fds)-1].Message()
+
+		for _, e := range res.Expand {
+			if e.Resource == "" {
+				e.Resource = e.Name
+			}
+			if e.Target = byName[e.Resource]; e.Target == nil {
+				return fmt.Errorf("resource %s: expand %s: unknown resource %s", res.Name, e.Name, e.Resource)
+			}
+			if _, err := findField(res.Get.Output(), e.Field); err != nil {
+				return fmt.Errorf("resource %s: expand %s: %w", res.Name, e.Name, err)
+			}
+			if item != nil {
+				if _, err := findField(item, e.Field); err != nil {
+					return fmt.Errorf("resource %s: expand %s: %w", res.Name, e.Name, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseExpand returns the expansions named in expr
+func (res *Resource) ParseExpand(expr string) ([]*Expansion, error) {
+	var expansions []*Expansion
+	seen := map[string]bool{}
+
+	for _, name := range strings.Split(expr, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		e := res.expansion(name)
+		if e == nil {
+			names := make([]string, 0, len(res.Expand))
+			for _, e := range res.Expand {
+				names = append(names, e.Name)
+			}
+			return nil, fmt.Errorf("can not expand %q, expandable: %s", name, strings.Join(names, ", "))
+		}
+		expansions = append(expansions, e)
+	}
+
+	return expansions, nil
+}
+
+func (res *Resource) expansion(name string) *Expansion {
+	for _, e := range res.Expand {
+		if e.Name == name {
+			return e
+		}
+	}
+
+	return nil
+}
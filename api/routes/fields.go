@@ -0,0 +1,168 @@
+package routes
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Sparse fieldsets are requested with the fields query parameter or the X-Fields header,
+// e.g. fields=id,name,positions.position_attributes.value
+const (
+	ParamFields  = "fields"
+	HeaderFields = "X-Fields"
+)
+
+// FieldSelection is a parsed sparse fieldset, a tree of the selected fields.
+// A selected message field without children is kept whole.
+type FieldSelection struct {
+	children map[string]*FieldSelection
+}
+
+// ParseFieldSelection parses comma separated field paths of md, proto and JSON names are accepted
+func ParseFieldSelection(md protoreflect.MessageDescriptor, expr string) (*FieldSelection, error) {
+	root := &FieldSelection{children: map[string]*FieldSelection{}}
+
+	for _, path := range strings.Split(expr, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		node, msg := root, md
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			if msg == nil {
+				return nil, fmt.Errorf("field %q: %s has no fields", path, strings.Join(names[:i], "."))
+			}
+			fd := msg.Fields().ByName(protoreflect.Name(name))
+			if fd == nil {
+				fd = msg.Fields().ByJSONName(name)
+			}
+			if fd == nil {
+				return nil, fmt.Errorf("unknown field %q in %s", path, msg.FullName())
+			}
+
+			child, ok := node.children[string(fd.Name())]
+			switch {
+			case ok && child.children == nil:
+				// the field is already selected whole
+			case i == len(names)-1:
+				child = &FieldSelection{}
+			case !ok:
+				child = &FieldSelection{children: map[string]*FieldSelection{}}
+			}
+			node.children[string(fd.Name())] = child
+			if child.children == nil {
+				break
+			}
+
+			node, msg = child, elementMessage(fd)
+		}
+	}
+
+	if len(root.children) == 0 {
+		return nil, fmt.Errorf("no field selected")
+	}
+
+	return root, nil
+}
+
+// Prune clears the fields of m that are not selected
+func (s *FieldSelection) Prune(m protoreflect.Message) {
+	var unselected []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		child, ok := s.children[string(fd.Name())]
+		switch {
+		case !ok:
+			unselected = append(unselected, fd)
+		case child.children == nil:
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				child.Prune(list.Get(i).Message())
+			}
+		case fd.IsMap():
+			v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				child.Prune(v.Message())
+				return true
+			})
+		default:
+			child.Prune(v.Message())
+		}
+		return true
+	})
+
+	for _, fd := range unselected {
+		m.Clear(fd)
+	}
+}
+
+// filter drops the unselected keys of doc, the JSON form of a message of md
+func (s *FieldSelection) filter(doc interface{}, md protoreflect.MessageDescriptor) {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for key, value := range obj {
+		fd := jsonField(md, key)
+		if fd == nil {
+			delete(obj, key)
+			continue
+		}
+		child, ok := s.children[string(fd.Name())]
+		if !ok {
+			delete(obj, key)
+			continue
+		}
+		if child.children == nil {
+			continue
+		}
+
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				child.filter(item, elementMessage(fd))
+			}
+		case map[string]interface{}:
+			if fd.IsMap() {
+				for _, item := range v {
+					child.filter(item, elementMessage(fd))
+				}
+			} else {
+				child.filter(v, elementMessage(fd))
+			}
+		}
+	}
+}
+
+// elementMessage returns the message of a field, of its elements or of its map values,
+// nil for scalars and well-known types that are encoded as JSON values
+func elementMessage(fd protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+	md := fd.Message()
+	if fd.IsMap() {
+		md = fd.MapValue().Message()
+	}
+	if md == nil || strings.HasPrefix(string(md.FullName()), "google.protobuf.") {
+		return nil
+	}
+
+	return md
+}
+
+// DataMessage returns the message descriptor of the data a route responds with,
+// nil when the data is not a message
+func (r *Route) DataMessage() protoreflect.MessageDescriptor {
+	if r.Resource != nil && r.Action == ActionList {
+		fds, _ := findField(r.RPC.Output(), r.Resource.ItemsField)
+		return fds[len(fds)-1].Message()
+	}
+	if r.ResponseBody == "" {
+		return r.RPC.Output()
+	}
+
+	fds, _ := findField(r.RPC.Output(), r.ResponseBody)
+	return fds[len(fds)-1].Message()
+}
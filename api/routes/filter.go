@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/xfirdavs/api_gateway/pkg/util"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FormatUUID is the filter format of ids
+const FormatUUID = "uuid"
+
+// Filter binds a query parameter of a list route to a request field. A route with
+// filters rejects every other query parameter. Repeated fields take several values,
+// as repeated parameters or separated by commas.
+type Filter struct {
+	Name        string `yaml:"name"`
+	Field       string `yaml:"field"`
+	Format      string `yaml:"format"`
+	Description string `yaml:"description"`
+
+	fd protoreflect.FieldDescriptor
+}
+
+// FilterError lists every invalid query parameter of a request
+type FilterError []string
+
+func (e FilterError) Error() string {
+	return strings.Join(e, "; ")
+}
+
+// paginationFilters are the offset pagination parameters of the resource list routes
+var paginationFilters = []Filter{
+	{Name: "limit", Description: "maximum number of items"},
+	{Name: "offset", Description: "number of items to skip"},
+}
+
+func (f *Filter) resolve(md protoreflect.MessageDescriptor) error {
+	if f.Field == "" {
+		f.Field = f.Name
+	}
+
+	fds, err := findField(md, f.Field)
+	if err != nil {
+		return fmt.Errorf("filter %s: %w", f.Name, err)
+	}
+	f.fd = fds[len(fds)-1]
+	if f.fd.Message() != nil || f.fd.IsMap() {
+		return fmt.Errorf("filter %s: field %s is not a scalar", f.Name, f.Field)
+	}
+
+	switch f.Format {
+	case "":
+	case FormatUUID:
+		if f.fd.Kind() != protoreflect.StringKind {
+			return fmt.Errorf("filter %s: format uuid needs a string field", f.Name)
+		}
+	default:
+		return fmt.Errorf("filter %s: unknown format %q", f.Name, f.Format)
+	}
+
+	return nil
+}
+
+// bindFilters sets the fields of the filters in query, collecting every problem
+func (r *Route) bindFilters(msg protoreflect.Message, query url.Values) error {
+	filters := make(map[string]Filter, len(r.Filters))
+	for _, f := range r.Filters {
+		filters[f.Name] = f
+	}
+	var errs FilterError
+	for key, values := range query {
+		if r.HasParam(key) {
+			continue
+		}
+		f, ok := filters[key]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("unknown query parameter %q", key))
+			continue
+		}
+
+		if f.fd.IsList() {
+			var split []string
+			for _, value := range values {
+				split = append(split, strings.Split(value, ",")...)
+			}
+			values = split
+		} else if len(values) > 1 {
+			errs = append(errs, fmt.Sprintf("%s takes one value", key))
+			continue
+		}
+
+		valid := true
+		for _, value := range values {
+			if f.Format == FormatUUID && !util.IsValidUUID(value) {
+				errs = append(errs, fmt.Sprintf("%s: %q is not a valid UUID", key, value))
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		if err := SetField(msg, f.Field, values); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return errs
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gopkg.in/yaml.v2"
+)
+
+// Media types of the formats the gateway reads and writes besides JSON,
+// application/x-msgpack and application/x-yaml are accepted as aliases
+const (
+	MIMEProtobuf = binding.MIMEPROTOBUF
+	MIMEMsgPack  = binding.MIMEMSGPACK2
+	MIMEYAML     = "application/yaml"
+)
+
+// ErrUnsupportedMediaType is returned for a request body of a format the gateway can not read
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// ResponseFormats are the media types offered to the Accept header, JSON first as the default
+var ResponseFormats = []string{
+	binding.MIMEJSON,
+	MIMEProtobuf,
+	MIMEMsgPack,
+	binding.MIMEMSGPACK,
+	MIMEYAML,
+	binding.MIMEYAML,
+}
+
+// Formats are the canonical media types of the response formats
+var Formats = []string{binding.MIMEJSON, MIMEProtobuf, MIMEMsgPack, MIMEYAML}
+
+// MediaType returns the canonical media type of a format, resolving the aliases
+func MediaType(mediaType string) string {
+	switch mediaType {
+	case binding.MIMEMSGPACK:
+		return MIMEMsgPack
+	case binding.MIMEYAML, "text/yaml":
+		return MIMEYAML
+	}
+
+	return mediaType
+}
+
+// NegotiateFormat returns the canonical media type of the response format preferred by an Accept
+// header, JSON when the header is empty and "" when no format is acceptable
+func NegotiateFormat(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return binding.MIMEJSON
+	}
+
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+
+		for _, offer := range ResponseFormats {
+			if mediaRange == "*/*" || mediaRange == offer ||
+				strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaRange, "*")) {
+				best, bestQ = offer, q
+				break
+			}
+		}
+	}
+
+	return MediaType(best)
+}
+
+// IsJSON reports whether bodies of mediaType are read as they are, the patch formats
+// included; an empty media type is JSON
+func IsJSON(mediaType string) bool {
+	switch mediaType {
+	case "", binding.MIMEJSON, ContentTypeMergePatch, ContentTypeJSONPatch:
+		return true
+	}
+
+	return false
+}
+
+// BodyMessage returns the message a request body of the route is decoded into, nil for routes without a body.
+// A PATCH body is an Update request, in protobuf a merge patch of its populated fields.
+func (r *Route) BodyMessage() protoreflect.MessageDescriptor {
+	if r.Action == ActionPatch {
+		return r.Resource.Update.Input()
+	}
+	if r.Body == "" {
+		return nil
+	}
+
+	md := r.Input.Descriptor()
+	if r.Body == "*" {
+		return md
+	}
+	fds, _ := findField(md, r.Body)
+
+	return fds[len(fds)-1].Message()
+}
+
+// TranscodeBody converts a request body of mediaType to JSON, protobuf bodies are binary messages of md.
+// Errors are *DecodeError, or ErrUnsupportedMediaType for other formats. The body size limit of the
+// route applies to the body as sent and again once converted.
+func TranscodeBody(mediaType string, data []byte, md protoreflect.MessageDescriptor) ([]byte, error) {
+	var doc interface{}
+	switch MediaType(mediaType) {
+	case MIMEProtobuf:
+		if md == nil {
+			return nil, fmt.Errorf("%w %s", ErrUnsupportedMediaType, mediaType)
+		}
+		msg := dynamicpb.NewMessage(md)
+		if err := proto.Unmarshal(data, msg); err != nil {
+			return nil, &DecodeError{Reason: "invalid protobuf: " + err.Error()}
+		}
+		return protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	case MIMEMsgPack:
+		if err := binding.MsgPack.BindBody(data, &doc); err != nil {
+			return nil, &DecodeError{Reason: "invalid MessagePack: " + err.Error()}
+		}
+	case MIMEYAML:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, &DecodeError{Reason: "invalid YAML: " + err.Error()}
+		}
+	default:
+		return nil, fmt.Errorf("%w %s", ErrUnsupportedMediaType, mediaType)
+	}
+
+	doc, err := jsonValue(doc)
+	if err != nil {
+		return nil, &DecodeError{Reason: err.Error()}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, &DecodeError{Reason: err.Error()}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// jsonValue converts a value decoded from MessagePack or YAML to a JSON value,
+// their maps may have keys of any type and MessagePack strings may be bytes
+func jsonValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			var name string
+			switch k := key.(type) {
+			case string:
+				name = k
+			case []byte:
+				name = string(k)
+			default:
+				return nil, fmt.Errorf("object keys must be strings, got %v", key)
+			}
+			var err error
+			if obj[name], err = jsonValue(value); err != nil {
+				return nil, err
+			}
+		}
+		return obj, nil
+	case map[string]interface{}:
+		for key, value := range v {
+			var err error
+			if v[key], err = jsonValue(value); err != nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			var err error
+			if v[i], err = jsonValue(item); err != nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+
+	return v, nil
+}
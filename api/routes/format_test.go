@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"github.com/xfirdavs/api_gateway/genproto/company_service"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept, want string
+	}{
+		{accept: "", want: binding.MIMEJSON},
+		{accept: "application/json", want: binding.MIMEJSON},
+		{accept: "*/*", want: binding.MIMEJSON},
+		{accept: "application/*", want: binding.MIMEJSON},
+		{accept: "application/x-protobuf", want: MIMEProtobuf},
+		{accept: "application/msgpack", want: MIMEMsgPack},
+		{accept: "application/x-msgpack", want: MIMEMsgPack},
+		{accept: "application/x-yaml", want: MIMEYAML},
+		{accept: "application/yaml; charset=utf-8", want: MIMEYAML},
+		{accept: "application/json;q=0.5, application/yaml", want: MIMEYAML},
+		{accept: "application/yaml;q=0.2, application/msgpack;q=0.8", want: MIMEMsgPack},
+		// the first of equally preferred ranges wins
+		{accept: "application/msgpack, application/json", want: MIMEMsgPack},
+		{accept: "text/html, */*;q=0.1", want: binding.MIMEJSON},
+		{accept: "application/yaml;q=x, application/json;q=0.1", want: binding.MIMEJSON},
+		{accept: "application/json;q=0", want: ""},
+		{accept: "text/html", want: ""},
+		{accept: "text/*", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			if got := NegotiateFormat(tt.accept); got != tt.want {
+				t.Errorf("NegotiateFormat(%q) = %q, want %q", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func msgpack(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	w := httptest.NewRecorder()
+	if err := (render.MsgPack{Data: v}).Render(w); err != nil {
+		t.Fatal(err)
+	}
+
+	return w.Body.Bytes()
+}
+
+func TestTranscodeBody(t *testing.T) {
+	company, err := proto.Marshal(&company_service.CreateCompanyRequest{Name: "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := (&company_service.CreateCompanyRequest{}).ProtoReflect().Descriptor()
+
+	tests := []struct {
+		name      string
+		mediaType string
+		body      []byte
+		md        bool
+		want      map[string]interface{}
+		// unsupported expects ErrUnsupportedMediaType, invalid a *DecodeError
+		unsupported bool
+		invalid     bool
+	}{
+		{name: "yaml", mediaType: MIMEYAML, body: []byte("name: acme\nsize: 3\ntags: [a, b]\n"),
+			want: map[string]interface{}{"name": "acme", "size": 3.0, "tags": []interface{}{"a", "b"}}},
+		{name: "yaml alias", mediaType: "application/x-yaml", body: []byte("name: acme\n"),
+			want: map[string]interface{}{"name": "acme"}},
+		{name: "nested yaml", mediaType: MIMEYAML, body: []byte("owner:\n  name: a\n"),
+			want: map[string]interface{}{"owner": map[string]interface{}{"name": "a"}}},
+		{name: "yaml with other keys", mediaType: MIMEYAML, body: []byte("1: acme\n"), invalid: true},
+		{name: "invalid yaml", mediaType: MIMEYAML, body: []byte("name: [acme\n"), invalid: true},
+		{name: "msgpack", mediaType: MIMEMsgPack, body: msgpack(t, map[string]interface{}{"name": "acme", "size": 3}),
+			want: map[string]interface{}{"name": "acme", "size": 3.0}},
+		{name: "msgpack alias", mediaType: "application/x-msgpack", body: msgpack(t, map[string]interface{}{"name": "acme"}),
+			want: map[string]interface{}{"name": "acme"}},
+		{name: "invalid msgpack", mediaType: MIMEMsgPack, body: []byte{0xc1}, invalid: true},
+		{name: "protobuf", mediaType: MIMEProtobuf, body: company, md: true,
+			want: map[string]interface{}{"name": "acme"}},
+		{name: "invalid protobuf", mediaType: MIMEProtobuf, body: []byte{0xff}, md: true, invalid: true},
+		{name: "protobuf without message", mediaType: MIMEProtobuf, body: company, unsupported: true},
+		{name: "xml", mediaType: "application/xml", body: []byte("<name>acme</name>"), unsupported: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc := md
+			if !tt.md {
+				desc = nil
+			}
+			data, err := TranscodeBody(tt.mediaType, tt.body, desc)
+
+			var decodeErr *DecodeError
+			switch {
+			case tt.unsupported:
+				if !errors.Is(err, ErrUnsupportedMediaType) {
+					t.Errorf("error %v, want ErrUnsupportedMediaType", err)
+				}
+				return
+			case tt.invalid:
+				if !errors.As(err, &decodeErr) {
+					t.Errorf("error %v, want a DecodeError", err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("TranscodeBody: %v", err)
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("%s is not JSON: %v", data, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body %s, want %v", data, tt.want)
+			}
+		})
+	}
+}
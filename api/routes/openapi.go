@@ -0,0 +1,387 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"github.com/xfirdavs/api_gateway/api/versioning"
+	"github.com/xfirdavs/api_gateway/pkg/idempotency"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	responseModelRef = "#/definitions/models.ResponseModel"
+	pageMetaRef      = "#/definitions/models.PageMeta"
+	violationName    = "routes.Violation"
+)
+
+// SwaggerDoc is a swagger document that can be registered with swag.Register
+type SwaggerDoc string
+
+// ReadDoc implements swag.Swagger
+func (d SwaggerDoc) ReadDoc() string {
+	return string(d)
+}
+
+// Swagger adds the operations of the routes and the schemas of their messages to
+// the swag generated document base. When version is set the document only has
+// the paths of that version and the paths without version prefix.
+func Swagger(base string, api *API, version string) (SwaggerDoc, error) {
+	var doc spec.Swagger
+	if err := json.Unmarshal([]byte(base), &doc); err != nil {
+		return "", err
+	}
+
+	if doc.Paths == nil {
+		doc.Paths = &spec.Paths{}
+	}
+	if doc.Paths.Paths == nil {
+		doc.Paths.Paths = map[string]spec.PathItem{}
+	}
+	if doc.Definitions == nil {
+		doc.Definitions = spec.Definitions{}
+	}
+
+	deprecated := false
+	if version != "" {
+		if doc.Info != nil {
+			doc.Info.Version = version
+		}
+		deprecated = api.Versions.Get(version).IsDeprecated()
+
+		for path, item := range doc.Paths.Paths {
+			first := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+			switch {
+			case first == version:
+				if deprecated {
+					deprecate(&item)
+				}
+				doc.Paths.Paths[path] = item
+			case versioning.IsVersion(first):
+				delete(doc.Paths.Paths, path)
+			}
+		}
+	}
+
+	for _, route := range api.Routes {
+		if version != "" && route.Version != version {
+			continue
+		}
+
+		path := route.template.swaggerPath()
+		item := doc.Paths.Paths[path]
+		op := route.operation(doc.Definitions)
+		if deprecated {
+			op.Deprecate()
+		}
+
+		switch route.Method {
+		case http.MethodGet:
+			item.Get = op
+		case http.MethodPut:
+			item.Put = op
+		case http.MethodPost:
+			item.Post = op
+		case http.MethodDelete:
+			item.Delete = op
+		case http.MethodPatch:
+			item.Patch = op
+		}
+		doc.Paths.Paths[path] = item
+	}
+	for _, b := range api.Batches {
+		if version != "" && b.Version != version {
+			continue
+		}
+
+		item := doc.Paths.Paths[b.Path]
+		item.Post = b.operation(doc.Definitions)
+		if deprecated {
+			item.Post.Deprecate()
+		}
+		doc.Paths.Paths[b.Path] = item
+	}
+	api.Validation.document(doc.Definitions)
+	api.Encoder.document(doc.Definitions)
+
+	data, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return "", err
+	}
+
+	return SwaggerDoc(data), nil
+}
+
+func deprecate(item *spec.PathItem) {
+	for _, op := range []*spec.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch, item.Head, item.Options} {
+		if op != nil {
+			op.Deprecate()
+		}
+	}
+}
+
+func (r *Route) operation(defs spec.Definitions) *spec.Operation {
+	op := spec.NewOperation(r.Selector).
+		WithSummary(r.Selector).
+		WithDescription(descriptionOf(r.RPC)).
+		WithTags(r.Tag).
+		WithConsumes("application/json", MIMEProtobuf, MIMEMsgPack, MIMEYAML).
+		WithProduces("application/json", MIMEProtobuf, MIMEMsgPack, MIMEYAML)
+
+	bound := map[string]bool{}
+	for _, s := range r.template.segments {
+		if s.field == "" {
+			continue
+		}
+		bound[s.field] = true
+		op.AddParam(spec.PathParam(s.param()).Typed("string", "").WithDescription(s.field))
+	}
+
+	input := r.RPC.Input()
+	switch r.Body {
+	case "":
+	case "*":
+		op.AddParam(spec.BodyParam("body", spec.RefSchema(addDefinition(defs, input))).AsRequired())
+	default:
+		fds, _ := findField(input, r.Body)
+		bound[r.Body] = true
+		op.AddParam(spec.BodyParam(r.Body, fieldSchema(defs, fds[len(fds)-1])).AsRequired())
+	}
+
+	if r.Filters != nil {
+		for _, f := range r.Filters {
+			p := queryParam(f.fd, r.Defaults[f.Field])
+			p.Name = f.Name
+			p.Description = f.Description
+			if f.Format == FormatUUID {
+				if p.Items != nil {
+					p.Items.Format = FormatUUID
+				} else {
+					p.Format = FormatUUID
+				}
+			}
+			op.AddParam(p)
+		}
+	} else if r.Body != "*" {
+		fields := input.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if bound[string(fd.Name())] || fd.Message() != nil || fd.IsMap() {
+				continue
+			}
+			op.AddParam(queryParam(fd, r.Defaults[string(fd.Name())]))
+		}
+	}
+
+	if r.DataMessage() != nil && r.Status != http.StatusNoContent {
+		op.AddParam(spec.QueryParam(ParamFields).Typed("string", "").
+			WithDescription("comma separated fields of data to return, nested as positions.position_attributes.value"))
+		op.AddParam(spec.HeaderParam(HeaderFields).Typed("string", "").
+			WithDescription("same as the fields query parameter"))
+	}
+	if r.HasParam(ParamExpand) {
+		names := make([]string, 0, len(r.Resource.Expand))
+		for _, e := range r.Resource.Expand {
+			names = append(names, e.Name)
+		}
+		op.AddParam(spec.QueryParam(ParamExpand).Typed("string", "").
+			WithDescription("comma separated related resources embedded in data: " + strings.Join(names, ", ") +
+				"; those that can not be fetched are null and listed in missing_expansions"))
+	}
+
+	data := spec.RefSchema(addDefinition(defs, r.RPC.Output()))
+	if r.ResponseBody != "" {
+		fds, _ := findField(r.RPC.Output(), r.ResponseBody)
+		data = fieldSchema(defs, fds[len(fds)-1])
+	}
+
+	success := wrapped("desc", "data", *data)
+	if r.Action == ActionList {
+		items, _ := findField(r.RPC.Output(), r.Resource.ItemsField)
+		success = spec.NewResponse().
+			WithDescription("desc").
+			WithSchema(new(spec.Schema).WithAllOf(
+				*spec.RefSchema(responseModelRef),
+				*new(spec.Schema).Typed("object", "").
+					SetProperty("data", *fieldSchema(defs, items[len(items)-1])).
+					SetProperty("meta", *spec.RefSchema(pageMetaRef)),
+			)).
+			AddHeader("Link", spec.ResponseHeader().Typed("string", "").WithDescription(`links to the next and prev pages, RFC 8288`))
+
+		op.AddParam(spec.QueryParam(ParamCursor).Typed("string", "").
+			WithDescription("opaque cursor from meta.next_cursor or meta.prev_cursor, replaces offset"))
+		if r.Resource.Sort != nil {
+			op.AddParam(spec.QueryParam(ParamSort).Typed("string", "").
+				WithDescription("comma separated fields, prefixed with - for descending order: " + strings.Join(r.Resource.Sort.Fields, ", ")))
+		}
+		for i := range op.Parameters {
+			if op.Parameters[i].Name == "limit" && op.Parameters[i].In == "query" {
+				op.Parameters[i].WithMinimum(1, false).WithMaximum(float64(r.Resource.MaxPageSize), false)
+			}
+		}
+	}
+	if r.Status == http.StatusNoContent {
+		success = spec.NewResponse().WithDescription("No Content")
+	}
+	if r.Action == ActionCreate {
+		success.AddHeader("Location", spec.ResponseHeader().Typed("string", "").WithDescription("URL of the created resource"))
+	}
+	if r.Method == http.MethodGet {
+		success.AddHeader(HeaderETag, spec.ResponseHeader().Typed("string", "").
+			WithDescription("strong entity tag of the response, suffixed with the content coding when compressed"))
+		op.AddParam(spec.HeaderParam(HeaderIfNoneMatch).Typed("string", "").
+			WithDescription("entity tags of the cached response, answered 304 when it did not change. " +
+				"Only entity tags are compared: responses carry no Last-Modified and If-Modified-Since is ignored"))
+		op.RespondsWith(http.StatusNotModified, spec.NewResponse().WithDescription("Not Modified"))
+	}
+	if r.Method == http.MethodPost || r.Method == http.MethodPatch {
+		op.AddParam(spec.HeaderParam(idempotency.Header).Typed("string", "").
+			WithDescription(fmt.Sprintf("unique key of the request, at most %d characters: repeats get the stored response with %s: true", idempotency.MaxKeyLength, idempotency.ReplayedHeader)))
+		op.RespondsWith(http.StatusConflict, wrapped("Conflict, the Idempotency-Key was sent with another request", "error", *spec.StringProperty()))
+		op.RespondsWith(http.StatusTooEarly, wrapped("Too Early, the first request with the Idempotency-Key is in flight", "error", *spec.StringProperty()))
+	}
+	if r.Modifies != nil {
+		op.AddParam(spec.HeaderParam(HeaderIfMatch).Typed("string", "").
+			WithDescription("ETag of GET on the " + r.Modifies.Name + " read before, answered 412 when it changed since. " +
+				"Best-effort: a change made between the check and the write is not detected"))
+		op.RespondsWith(http.StatusPreconditionFailed, wrapped("Precondition Failed, the "+r.Modifies.Name+" changed since it was read", "error", *spec.StringProperty()))
+	}
+	op.RespondsWith(r.Status, success)
+	if r.Resource != nil && r.Action != ActionCreate && r.Action != ActionList {
+		op.RespondsWith(http.StatusNotFound, wrapped("Not Found", "error", *spec.StringProperty()))
+	}
+	if r.Action == ActionPatch {
+		op.Consumes = append(op.Consumes, ContentTypeMergePatch, ContentTypeJSONPatch)
+		op.Description = strings.TrimSpace(op.Description + "\n\nPartial update with a JSON merge patch (RFC 7396) or a JSON Patch (RFC 6902).")
+		if len(r.Resource.Mutable) > 0 {
+			op.Description += " Mutable fields: " + strings.Join(r.Resource.Mutable, ", ") + "."
+		}
+		op.RespondsWith(http.StatusConflict, wrapped("Conflict, a JSON Patch test failed or the Idempotency-Key was sent with another request", "error", *spec.StringProperty()))
+		op.RespondsWith(http.StatusUnprocessableEntity, wrapped("Immutable field, patch not applicable, unknown reference or invalid attribute value", "error", *spec.StringProperty()))
+	} else if r.Modifies != nil && r.RPC == r.Modifies.Update && r.Modifies.RestrictsUpdates() {
+		op.Description = strings.TrimSpace(op.Description + "\n\nMutable fields: " + strings.Join(r.Modifies.Mutable, ", ") + ", the others must keep their current value.")
+		op.RespondsWith(http.StatusUnprocessableEntity, wrapped("Immutable field changed, unknown reference, attribute type or invalid attribute value", "error", *spec.ArrayProperty(spec.StringProperty())))
+	} else if r.Writes != nil && (len(r.Writes.References) > 0 || r.Writes.TypeField != "") {
+		op.RespondsWith(http.StatusUnprocessableEntity, wrapped("Unknown reference, attribute type or invalid attribute value", "error", *spec.ArrayProperty(spec.StringProperty())))
+	}
+	op.RespondsWith(http.StatusNotAcceptable, wrapped("Not Acceptable, the Accept header names no supported format", "error", *spec.StringProperty()))
+	if r.Body != "" {
+		op.RespondsWith(http.StatusUnsupportedMediaType, wrapped("Unsupported Media Type", "error", *spec.StringProperty()))
+		op.RespondsWith(http.StatusRequestEntityTooLarge, wrapped(
+			fmt.Sprintf("Request Entity Too Large, the body is limited to %d bytes", r.MaxBodySize), "error", *spec.StringProperty()))
+	}
+	if r.Validation != nil {
+		defs[violationName] = *new(spec.Schema).Typed("object", "").
+			SetProperty("field", *spec.StringProperty()).
+			SetProperty("description", *spec.StringProperty())
+		op.RespondsWith(http.StatusBadRequest, wrapped("Bad Request, error lists the violations when validation failed", "error",
+			*spec.ArrayProperty(spec.RefSchema("#/definitions/" + violationName))))
+	} else {
+		op.RespondsWith(http.StatusBadRequest, wrapped("Bad Request", "error", *spec.StringProperty()))
+	}
+	op.RespondsWith(http.StatusInternalServerError, wrapped("Server Error", "error", *spec.StringProperty()))
+
+	return op
+}
+
+func wrapped(description, property string, schema spec.Schema) *spec.Response {
+	return spec.NewResponse().
+		WithDescription(description).
+		WithSchema(new(spec.Schema).WithAllOf(
+			*spec.RefSchema(responseModelRef),
+			*new(spec.Schema).Typed("object", "").SetProperty(property, schema),
+		))
+}
+
+func queryParam(fd protoreflect.FieldDescriptor, defaultValue string) *spec.Parameter {
+	s := scalarSchema(fd)
+	p := spec.QueryParam(string(fd.Name())).Typed(s.Type[0], s.Format)
+	if fd.IsList() {
+		p = spec.QueryParam(string(fd.Name())).CollectionOf(spec.NewItems().Typed(s.Type[0], s.Format), "multi")
+	}
+	if len(s.Enum) > 0 {
+		p.WithEnum(s.Enum...)
+	}
+	if defaultValue != "" {
+		p.WithDefault(defaultValue)
+	}
+
+	return p
+}
+
+// addDefinition adds the schema of md and of every message it references, returning its $ref
+func addDefinition(defs spec.Definitions, md protoreflect.MessageDescriptor) string {
+	name := string(md.FullName())
+	ref := "#/definitions/" + name
+	if _, ok := defs[name]; ok {
+		return ref
+	}
+
+	schema := new(spec.Schema).Typed("object", "")
+	schema.Description = descriptionOf(md)
+	defs[name] = *schema
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		schema.SetProperty(string(fd.Name()), *fieldSchema(defs, fd))
+	}
+	defs[name] = *schema
+
+	return ref
+}
+
+func fieldSchema(defs spec.Definitions, fd protoreflect.FieldDescriptor) *spec.Schema {
+	if fd.IsMap() {
+		return spec.MapProperty(fieldSchema(defs, fd.MapValue()))
+	}
+
+	var s *spec.Schema
+	if fd.Message() != nil {
+		s = spec.RefSchema(addDefinition(defs, fd.Message()))
+	} else {
+		s = scalarSchema(fd)
+	}
+
+	if fd.IsList() {
+		return spec.ArrayProperty(s)
+	}
+
+	return s
+}
+
+func scalarSchema(fd protoreflect.FieldDescriptor) *spec.Schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return spec.BoolProperty()
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return spec.Int32Property()
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return spec.Int64Property()
+	case protoreflect.FloatKind:
+		return spec.Float32Property()
+	case protoreflect.DoubleKind:
+		return spec.Float64Property()
+	case protoreflect.BytesKind:
+		return spec.StrFmtProperty("byte")
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]interface{}, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return spec.StringProperty().WithEnum(names...)
+	}
+
+	return spec.StringProperty()
+}
+
+func descriptionOf(d protoreflect.Descriptor) string {
+	loc := d.ParentFile().SourceLocations().ByDescriptor(d)
+	return strings.TrimSpace(loc.LeadingComments)
+}
@@ -0,0 +1,423 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Content types of PATCH bodies, plain JSON is read as a merge patch
+const (
+	ContentTypeMergePatch = "application/merge-patch+json"
+	ContentTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupportedPatch is returned for a PATCH body that is neither JSON merge patch nor JSON Patch
+	ErrUnsupportedPatch = errors.New("unsupported patch content type")
+	// ErrPatchTestFailed is returned when a JSON Patch test operation does not match
+	ErrPatchTestFailed = errors.New("patch test failed")
+)
+
+// Patch is a PATCH body, either a JSON merge patch (RFC 7396) or a JSON Patch (RFC 6902),
+// with the field names already mapped to the proto names of the target message
+type Patch struct {
+	merge     map[string]interface{}
+	ops       []patchOp
+	jsonPatch bool
+	paths     []string
+}
+
+type patchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from"`
+	Value interface{} `json:"value"`
+}
+
+// ParsePatch parses body according to contentType and checks every touched field exists in md
+func ParsePatch(contentType string, body []byte, md protoreflect.MessageDescriptor) (*Patch, error) {
+	mediaType := "application/json"
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &Patch{}
+	switch mediaType {
+	case "application/json", ContentTypeMergePatch:
+		var merge map[string]interface{}
+		if err := json.Unmarshal(body, &merge); err != nil {
+			return nil, err
+		}
+		var err error
+		if p.merge, err = p.normalizeMerge(md, merge, ""); err != nil {
+			return nil, err
+		}
+	case ContentTypeJSONPatch:
+		p.jsonPatch = true
+		if err := json.Unmarshal(body, &p.ops); err != nil {
+			return nil, err
+		}
+		for i := range p.ops {
+			if err := p.normalizeOp(md, &p.ops[i]); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w %s", ErrUnsupportedPatch, mediaType)
+	}
+
+	return p, nil
+}
+
+// IsMerge reports whether the patch is a merge patch, which can be applied without the current resource
+func (p *Patch) IsMerge() bool {
+	return !p.jsonPatch
+}
+
+// Mask returns the field mask of the fields the patch touches, repeated and map
+// fields are touched as a whole
+func (p *Patch) Mask() *fieldmaskpb.FieldMask {
+	mask := &fieldmaskpb.FieldMask{Paths: append([]string(nil), p.paths...)}
+	mask.Normalize()
+
+	return mask
+}
+
+// Apply applies the patch to current, which may be nil, and decodes the result into req
+func (p *Patch) Apply(current, req proto.Message) error {
+	var doc interface{} = map[string]interface{}{}
+	if current != nil {
+		data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(current)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+	}
+
+	if p.IsMerge() {
+		doc = mergeValue(doc, p.merge)
+	}
+	for i, op := range p.ops {
+		var err error
+		if doc, err = op.apply(doc); err != nil {
+			return fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, req)
+}
+
+// normalizeMerge renames the keys of a merge patch object to proto names and collects the touched paths
+func (p *Patch) normalizeMerge(md protoreflect.MessageDescriptor, obj map[string]interface{}, prefix string) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(obj))
+	for key, value := range obj {
+		fd, err := findField(md, key)
+		if err != nil {
+			return nil, err
+		}
+		name := string(fd[0].Name())
+
+		nested, ok := value.(map[string]interface{})
+		if ok && isPlainMessage(fd[0]) {
+			if out[name], err = p.normalizeMerge(fd[0].Message(), nested, prefix+name+"."); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		out[name] = value
+		p.paths = append(p.paths, prefix+name)
+	}
+
+	return out, nil
+}
+
+// normalizeOp renames the fields in the JSON pointers of op to proto names and collects the touched paths
+func (p *Patch) normalizeOp(md protoreflect.MessageDescriptor, op *patchOp) error {
+	var err error
+	var path string
+
+	switch op.Op {
+	case "add", "remove", "replace":
+	case "move":
+		if op.From, path, err = normalizePointer(md, op.From); err != nil {
+			return err
+		}
+		p.paths = append(p.paths, path)
+	case "copy", "test":
+		if op.From != "" {
+			if op.From, _, err = normalizePointer(md, op.From); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+
+	if op.Path, path, err = normalizePointer(md, op.Path); err != nil {
+		return err
+	}
+	if op.Op != "test" {
+		p.paths = append(p.paths, path)
+	}
+
+	return nil
+}
+
+// normalizePointer maps the field names of a JSON pointer to proto names and returns
+// it with the field path it touches, which stops at the first repeated or map field
+func normalizePointer(md protoreflect.MessageDescriptor, pointer string) (string, string, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return "", "", err
+	}
+	if len(tokens) == 0 {
+		return "", "", errors.New("the whole document can not be patched")
+	}
+
+	var fields []string
+	inField := true
+	for i := 0; i < len(tokens); i++ {
+		if md == nil {
+			break
+		}
+
+		fd := md.Fields().ByName(protoreflect.Name(tokens[i]))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(tokens[i])
+		}
+		if fd == nil {
+			return "", "", fmt.Errorf("unknown field %q in %s", tokens[i], md.FullName())
+		}
+		tokens[i] = string(fd.Name())
+		if inField {
+			fields = append(fields, tokens[i])
+		}
+
+		switch {
+		case fd.IsList() || fd.IsMap():
+			inField = false
+			i++ // skip the index or key
+			md = nil
+			if fd.IsList() && fd.Message() != nil {
+				md = fd.Message()
+			}
+			if fd.IsMap() && fd.MapValue().Message() != nil {
+				md = fd.MapValue().Message()
+			}
+		case isPlainMessage(fd):
+			md = fd.Message()
+		default:
+			md = nil
+		}
+	}
+
+	return formatPointer(tokens), strings.Join(fields, "."), nil
+}
+
+// isPlainMessage reports whether fd is a singular message encoded as a JSON object field by field,
+// unlike the well-known types with a JSON encoding of their own
+func isPlainMessage(fd protoreflect.FieldDescriptor) bool {
+	return fd.Message() != nil && !fd.IsList() && !fd.IsMap() &&
+		!strings.HasPrefix(string(fd.Message().FullName()), "google.protobuf.")
+}
+
+// mergeValue applies a JSON merge patch to target
+func mergeValue(target, patch interface{}) interface{} {
+	obj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	doc, ok := target.(map[string]interface{})
+	if !ok {
+		doc = map[string]interface{}{}
+	}
+	for key, value := range obj {
+		if value == nil {
+			delete(doc, key)
+			continue
+		}
+		doc[key] = mergeValue(doc[key], value)
+	}
+
+	return doc
+}
+
+func (op patchOp) apply(doc interface{}) (interface{}, error) {
+	path, _ := parsePointer(op.Path)
+
+	switch op.Op {
+	case "add", "replace", "remove":
+		return update(doc, path, op.Op, op.Value)
+	case "move", "copy":
+		from, _ := parsePointer(op.From)
+		value, err := lookup(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, err = update(doc, from, "remove", nil); err != nil {
+				return nil, err
+			}
+		} else if value, err = deepCopy(value); err != nil {
+			return nil, err
+		}
+		return update(doc, path, "add", value)
+	case "test":
+		value, err := lookup(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(value, op.Value) {
+			return nil, fmt.Errorf("%w: %s", ErrPatchTestFailed, op.Path)
+		}
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// update returns node with op applied at path
+func update(node interface{}, path []string, op string, value interface{}) (interface{}, error) {
+	key := path[0]
+
+	if len(path) > 1 {
+		child, err := lookup(node, path[:1])
+		if err != nil {
+			return nil, err
+		}
+		if child, err = update(child, path[1:], op, value); err != nil {
+			return nil, err
+		}
+		return update(node, path[:1], "replace", child)
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		_, ok := n[key]
+		if !ok && op != "add" {
+			return nil, fmt.Errorf("path %q does not exist", key)
+		}
+		if op == "remove" {
+			delete(n, key)
+		} else {
+			n[key] = value
+		}
+		return n, nil
+	case []interface{}:
+		if key == "-" && op == "add" {
+			return append(n, value), nil
+		}
+		i, err := strconv.Atoi(key)
+		last := len(n) - 1
+		if op == "add" {
+			last = len(n)
+		}
+		if err != nil || i < 0 || i > last {
+			return nil, fmt.Errorf("index %q is out of range", key)
+		}
+		switch op {
+		case "add":
+			n = append(n[:i], append([]interface{}{value}, n[i:]...)...)
+		case "remove":
+			n = append(n[:i], n[i+1:]...)
+		default:
+			n[i] = value
+		}
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("path %q does not exist", key)
+}
+
+func lookup(node interface{}, path []string) (interface{}, error) {
+	for _, key := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[key]
+			if !ok {
+				return nil, fmt.Errorf("path %q does not exist", key)
+			}
+			node = v
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("index %q is out of range", key)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("path %q does not exist", key)
+		}
+	}
+
+	return node, nil
+}
+
+func deepCopy(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out interface{}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// jsonEqual compares two decoded JSON values, numbers encoded as strings by protojson match plain numbers
+func jsonEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	s, ok := a.(string)
+	f, isNumber := b.(float64)
+	return ok && isNumber && s == strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func formatPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+
+	return b.String()
+}
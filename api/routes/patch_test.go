@@ -0,0 +1,269 @@
+package routes
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xfirdavs/api_gateway/genproto/position_service"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPatch(t *testing.T) {
+	current := &position_service.Position{
+		Id:           "1",
+		Name:         "a",
+		ProfessionId: "p1",
+		CompanyId:    "c1",
+		PositionAttributes: []*position_service.GetPositionAttributes{
+			{Id: "pa1", AttributeId: "x", Value: "1", AttributeName: "level"},
+		},
+	}
+	attributes := []*position_service.PositionAttributes{{AttributeId: "x", Value: "1"}}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		// current is patched when set, otherwise the patch is applied to an empty document
+		current proto.Message
+		want    *position_service.UpdatePositionRequest
+		mask    []string
+		// parseErr and applyErr expect ParsePatch or Apply to fail, with an error matching is when set
+		parseErr bool
+		applyErr bool
+		is       error
+	}{
+		// JSON merge patch, RFC 7396
+		{
+			name:    "merge replaces fields by proto and JSON name",
+			body:    `{"name":"b","companyId":"c2"}`,
+			current: current,
+			want:    &position_service.UpdatePositionRequest{Id: "1", Name: "b", ProfessionId: "p1", CompanyId: "c2", PositionAttributes: attributes},
+			mask:    []string{"company_id", "name"},
+		},
+		{
+			name:        "merge content type",
+			contentType: ContentTypeMergePatch + "; charset=utf-8",
+			body:        `{"name":"b"}`,
+			current:     current,
+			want:        &position_service.UpdatePositionRequest{Id: "1", Name: "b", ProfessionId: "p1", CompanyId: "c1", PositionAttributes: attributes},
+			mask:        []string{"name"},
+		},
+		{
+			name:    "merge null removes a field",
+			body:    `{"profession_id":null}`,
+			current: current,
+			want:    &position_service.UpdatePositionRequest{Id: "1", Name: "a", CompanyId: "c1", PositionAttributes: attributes},
+			mask:    []string{"profession_id"},
+		},
+		{
+			name:    "merge replaces a repeated field as a whole",
+			body:    `{"position_attributes":[{"attribute_id":"y","value":"2"}]}`,
+			current: current,
+			want: &position_service.UpdatePositionRequest{Id: "1", Name: "a", ProfessionId: "p1", CompanyId: "c1",
+				PositionAttributes: []*position_service.PositionAttributes{{AttributeId: "y", Value: "2"}}},
+			mask: []string{"position_attributes"},
+		},
+		{
+			name: "merge without current resource",
+			body: `{"name":"b"}`,
+			want: &position_service.UpdatePositionRequest{Name: "b"},
+			mask: []string{"name"},
+		},
+		{
+			name:     "merge unknown field",
+			body:     `{"salary":1}`,
+			parseErr: true,
+		},
+		{
+			name:     "merge not an object",
+			body:     `["name"]`,
+			parseErr: true,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        `{"name":"b"}`,
+			parseErr:    true,
+			is:          ErrUnsupportedPatch,
+		},
+
+		// JSON Patch, RFC 6902
+		{
+			name:        "replace by JSON name",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"replace","path":"/companyId","value":"c2"}]`,
+			current:     current,
+			want:        &position_service.UpdatePositionRequest{Id: "1", Name: "a", ProfessionId: "p1", CompanyId: "c2", PositionAttributes: attributes},
+			mask:        []string{"company_id"},
+		},
+		{
+			name:        "add to the end of a repeated field",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"add","path":"/position_attributes/-","value":{"attribute_id":"y","value":"2"}}]`,
+			current:     current,
+			want: &position_service.UpdatePositionRequest{Id: "1", Name: "a", ProfessionId: "p1", CompanyId: "c1",
+				PositionAttributes: []*position_service.PositionAttributes{{AttributeId: "x", Value: "1"}, {AttributeId: "y", Value: "2"}}},
+			mask: []string{"position_attributes"},
+		},
+		{
+			name:        "add at an index",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"add","path":"/position_attributes/0","value":{"attribute_id":"y","value":"2"}}]`,
+			current:     current,
+			want: &position_service.UpdatePositionRequest{Id: "1", Name: "a", ProfessionId: "p1", CompanyId: "c1",
+				PositionAttributes: []*position_service.PositionAttributes{{AttributeId: "y", Value: "2"}, {AttributeId: "x", Value: "1"}}},
+			mask: []string{"position_attributes"},
+		},
+		{
+			name:        "replace a nested field by JSON name",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"replace","path":"/positionAttributes/0/value","value":"3"}]`,
+			current:     current,
+			want: &position_service.UpdatePositionRequest{Id: "1", Name: "a", ProfessionId: "p1", CompanyId: "c1",
+				PositionAttributes: []*position_service.PositionAttributes{{AttributeId: "x", Value: "3"}}},
+			mask: []string{"position_attributes"},
+		},
+		{
+			name:        "remove",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"remove","path":"/position_attributes/0"}]`,
+			current:     current,
+			want:        &position_service.UpdatePositionRequest{Id: "1", Name: "a", ProfessionId: "p1", CompanyId: "c1"},
+			mask:        []string{"position_attributes"},
+		},
+		{
+			name:        "move",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"move","from":"/professionId","path":"/company_id"}]`,
+			current:     current,
+			want:        &position_service.UpdatePositionRequest{Id: "1", Name: "a", CompanyId: "p1", PositionAttributes: attributes},
+			mask:        []string{"company_id", "profession_id"},
+		},
+		{
+			name:        "copy",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"copy","from":"/name","path":"/company_id"}]`,
+			current:     current,
+			want:        &position_service.UpdatePositionRequest{Id: "1", Name: "a", ProfessionId: "p1", CompanyId: "a", PositionAttributes: attributes},
+			mask:        []string{"company_id"},
+		},
+		{
+			name:        "test passes",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"test","path":"/name","value":"a"},{"op":"replace","path":"/name","value":"b"}]`,
+			current:     current,
+			want:        &position_service.UpdatePositionRequest{Id: "1", Name: "b", ProfessionId: "p1", CompanyId: "c1", PositionAttributes: attributes},
+			mask:        []string{"name"},
+		},
+		{
+			name:        "test of a repeated field",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"test","path":"/position_attributes/0/value","value":"1"}]`,
+			current:     current,
+			want:        &position_service.UpdatePositionRequest{Id: "1", Name: "a", ProfessionId: "p1", CompanyId: "c1", PositionAttributes: attributes},
+		},
+		{
+			name:        "test fails",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"test","path":"/name","value":"b"},{"op":"replace","path":"/name","value":"c"}]`,
+			current:     current,
+			mask:        []string{"name"},
+			applyErr:    true,
+			is:          ErrPatchTestFailed,
+		},
+		{
+			name:        "unknown op",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"increment","path":"/name"}]`,
+			parseErr:    true,
+		},
+		{
+			name:        "unknown field",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"replace","path":"/salary","value":1}]`,
+			parseErr:    true,
+		},
+		{
+			name:        "unknown move source",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"move","from":"/salary","path":"/name"}]`,
+			parseErr:    true,
+		},
+		{
+			name:        "pointer without leading slash",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"replace","path":"name","value":"b"}]`,
+			parseErr:    true,
+		},
+		{
+			name:        "whole document",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"replace","path":"","value":{}}]`,
+			parseErr:    true,
+		},
+		{
+			name:        "not an array",
+			contentType: ContentTypeJSONPatch,
+			body:        `{"op":"replace","path":"/name","value":"b"}`,
+			parseErr:    true,
+		},
+		{
+			name:        "index out of range",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"remove","path":"/position_attributes/1"}]`,
+			current:     current,
+			mask:        []string{"position_attributes"},
+			applyErr:    true,
+		},
+		{
+			name:        "add past the end",
+			contentType: ContentTypeJSONPatch,
+			body:        `[{"op":"add","path":"/position_attributes/2","value":{}}]`,
+			current:     current,
+			mask:        []string{"position_attributes"},
+			applyErr:    true,
+		},
+	}
+
+	md := (&position_service.UpdatePositionRequest{}).ProtoReflect().Descriptor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParsePatch(tt.contentType, []byte(tt.body), md)
+			if tt.parseErr {
+				if err == nil {
+					t.Fatal("ParsePatch succeeded, want an error")
+				}
+				if tt.is != nil && !errors.Is(err, tt.is) {
+					t.Errorf("ParsePatch error %v, want %v", err, tt.is)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePatch: %v", err)
+			}
+			if got := patch.Mask().GetPaths(); !reflect.DeepEqual(got, tt.mask) {
+				t.Errorf("mask %v, want %v", got, tt.mask)
+			}
+
+			req := &position_service.UpdatePositionRequest{}
+			err = patch.Apply(tt.current, req)
+			if tt.applyErr {
+				if err == nil {
+					t.Fatal("Apply succeeded, want an error")
+				}
+				if tt.is != nil && !errors.Is(err, tt.is) {
+					t.Errorf("Apply error %v, want %v", err, tt.is)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !proto.Equal(req, tt.want) {
+				t.Errorf("got %v, want %v", req, tt.want)
+			}
+		})
+	}
+}
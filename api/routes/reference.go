@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Reference is an id field of the create and update requests of a resource that must name
+// an existing resource. The field path may go through repeated messages, as in
+// position_attributes.attribute_id, then every element is checked. Value names the field
+// next to the id holding a value of the attribute type of the referenced resource, values
+// of a stored type unknown to pkg/attrtype are not checked.
+//
+// Every route calling Create or Update checks the references with the Get method of the
+// target and answers 422 listing the ids that do not exist. Existing ids are remembered for
+// REFERENCE_CACHE_TTL and forgotten when the gateway updates or deletes the resource.
+type Reference struct {
+	Field    string `yaml:"field"`
+	Resource string `yaml:"resource"`
+	Value    string `yaml:"value"`
+
+	// Target is the referenced resource
+	Target *Resource `yaml:"-"`
+}
+
+// RefValue is an id found in a request for a reference, Path locates it as in
+// position_attributes[1].attribute_id. ValuePath and Value are set for references with a value.
+type RefValue struct {
+	Reference *Reference
+	Path      string
+	ID        string
+	ValuePath string
+	Value     string
+}
+
+// resolveReferences links the references of every resource to their target resource
+func resolveReferences(resources []*Resource) error {
+	byName := make(map[string]*Resource, len(resources))
+	for _, res := range resources {
+		byName[res.Name] = res
+	}
+
+	for _, res := range resources {
+		for _, ref := range res.References {
+			if err := ref.resolve(res, byName); err != nil {
+				return fmt.Errorf("resource %s: reference %s: %w", res.Name, ref.Field, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (ref *Reference) resolve(res *Resource, byName map[string]*Resource) error {
+	if ref.Target = byName[ref.Resource]; ref.Target == nil {
+		return fmt.Errorf("unknown resource %q", ref.Resource)
+	}
+	if ref.Value != "" && ref.Target.TypeField == "" {
+		return fmt.Errorf("value %s: resource %s has no type_field", ref.Value, ref.Resource)
+	}
+
+	found := false
+	for _, md := range []protoreflect.MessageDescriptor{res.Create.Input(), res.Update.Input()} {
+		parent, fd, err := refField(md, ref.Field)
+		if err != nil {
+			return err
+		}
+		if fd == nil {
+			continue
+		}
+		found = true
+
+		if ref.Value == "" {
+			continue
+		}
+		if fd.IsList() {
+			return fmt.Errorf("value %s: the ids of %s are a list", ref.Value, ref.Field)
+		}
+		if value := parent.Fields().ByName(protoreflect.Name(ref.Value)); value == nil || value.Kind() != protoreflect.StringKind || value.IsList() {
+			return fmt.Errorf("value %s is not a string field of %s", ref.Value, parent.FullName())
+		}
+	}
+	if !found {
+		return fmt.Errorf("no such field in %s or %s", res.Create.Input().FullName(), res.Update.Input().FullName())
+	}
+
+	return nil
+}
+
+// refField returns the string field at path of md and the message holding it, nil when
+// md has no such field. The path may go through repeated messages but not maps.
+func refField(md protoreflect.MessageDescriptor, path string) (protoreflect.MessageDescriptor, protoreflect.FieldDescriptor, error) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, nil, nil
+		}
+
+		if i == len(names)-1 {
+			if fd.Kind() != protoreflect.StringKind || fd.IsMap() {
+				return nil, nil, fmt.Errorf("field %s is not a string", path)
+			}
+			return md, fd, nil
+		}
+		if fd.Message() == nil || fd.IsMap() {
+			return nil, nil, fmt.Errorf("field %s in %s is not a message", name, md.FullName())
+		}
+		md = fd.Message()
+	}
+
+	return nil, nil, nil
+}
+
+// ReferencedIDs returns the non empty ids of msg, the request of the route, that must name existing resources
+func (r *Route) ReferencedIDs(msg proto.Message) []RefValue {
+	if r.Writes == nil {
+		return nil
+	}
+
+	var values []RefValue
+	for _, ref := range r.Writes.References {
+		ref := ref
+		collectRefs(msg.ProtoReflect(), strings.Split(ref.Field, "."), "", func(parent protoreflect.Message, path, id string) {
+			v := RefValue{Reference: ref, Path: path, ID: id}
+			if ref.Value != "" {
+				v.ValuePath = path[:strings.LastIndex(path, ".")+1] + ref.Value
+				v.Value = parent.Get(parent.Descriptor().Fields().ByName(protoreflect.Name(ref.Value))).String()
+			}
+			values = append(values, v)
+		})
+	}
+
+	return values
+}
+
+func collectRefs(msg protoreflect.Message, names []string, prefix string, found func(parent protoreflect.Message, path, id string)) {
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(names[0]))
+	if fd == nil {
+		fd = msg.Descriptor().Fields().ByJSONName(names[0])
+	}
+	if fd == nil {
+		return
+	}
+	path := prefix + string(fd.Name())
+
+	if !fd.IsList() {
+		if len(names) == 1 {
+			if id := msg.Get(fd).String(); id != "" {
+				found(msg, path, id)
+			}
+		} else if msg.Has(fd) {
+			collectRefs(msg.Get(fd).Message(), names[1:], path+".", found)
+		}
+		return
+	}
+
+	list := msg.Get(fd).List()
+	for i := 0; i < list.Len(); i++ {
+		element := fmt.Sprintf("%s[%d]", path, i)
+		if len(names) == 1 {
+			if id := list.Get(i).String(); id != "" {
+				found(msg, element, id)
+			}
+		} else {
+			collectRefs(list.Get(i).Message(), names[1:], element+".", found)
+		}
+	}
+}
+
+// AttributeType returns the attribute type the request of the route sets, for routes writing
+// a resource with a type_field. The path is empty when the resource has no type field.
+func (r *Route) AttributeType(msg proto.Message) (path, typ string) {
+	if r.Writes == nil || r.Writes.TypeField == "" {
+		return "", ""
+	}
+	if _, err := findField(msg.ProtoReflect().Descriptor(), r.Writes.TypeField); err != nil {
+		return "", ""
+	}
+
+	return r.Writes.TypeField, GetField(msg.ProtoReflect(), r.Writes.TypeField).String()
+}
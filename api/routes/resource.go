@@ -0,0 +1,365 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Actions of the RESTful routes of a resource
+const (
+	ActionCreate = "create"
+	ActionList   = "list"
+	ActionGet    = "get"
+	ActionUpdate = "update"
+	ActionPatch  = "patch"
+	ActionDelete = "delete"
+)
+
+const fieldMaskName = "google.protobuf.FieldMask"
+
+// defaultMaxPageSize is the largest limit of a list route when the resource sets none
+const defaultMaxPageSize = 100
+
+// ParamCursor is the query parameter of the list routes taking a page cursor
+const ParamCursor = "cursor"
+
+// restVersion is the first API version serving the RESTful resource routes
+const restVersion = "v2"
+
+// Resource is a CRUD service exposed with RESTful routes in every version from Since on:
+// POST and GET on /{version}/{name}, GET, PUT, PATCH and DELETE on /{version}/{name}/{id}.
+//
+// PATCH takes a JSON merge patch or a JSON Patch of the Update request, see Patch. A PUT,
+// PATCH or batch update changing a field that is not Mutable is answered 422.
+//
+// The list route takes limit, at most MaxPageSize, offset, cursor and the Filters, and answers
+// the items of ItemsField with the page meta {total, limit, has_more, next_cursor, prev_cursor},
+// total read from TotalField. Cursors are signed with CURSOR_SECRET, see pkg/cursor.
+type Resource struct {
+	Name    string          `yaml:"name"`
+	Service string          `yaml:"service"`
+	IDField string          `yaml:"id_field"`
+	Methods ResourceMethods `yaml:"methods"`
+	Since   string          `yaml:"since"`
+	Until   string          `yaml:"until"`
+
+	// Mutable lists the fields of the Update request a PATCH or PUT may change,
+	// every field but the id and the update mask when empty
+	Mutable []string `yaml:"mutable"`
+
+	// Filters are the query parameters of the list route besides limit, offset and cursor
+	Filters []Filter `yaml:"filters"`
+
+	// ItemsField and TotalField name the items and the total count in the List
+	// response, by default its repeated message field and "count"
+	ItemsField  string `yaml:"items_field"`
+	TotalField  string `yaml:"total_field"`
+	MaxPageSize int    `yaml:"max_page_size"`
+
+	// Sort allows ordering the list route, nil when the list can not be sorted
+	Sort *Sort `yaml:"sort"`
+
+	// Expand lists the related resources the get and list routes can embed
+	Expand []*Expansion `yaml:"expand"`
+
+	// References lists the id fields of the create and update requests that must name existing resources
+	References []*Reference `yaml:"references"`
+
+	// MaxBodySize limits the bodies of the create, update and patch requests in bytes
+	MaxBodySize int64 `yaml:"max_body_size"`
+
+	// TypeField names the attribute type of the resource, see pkg/attrtype. Creates and
+	// updates must set a known type and references with a value are checked against it.
+	TypeField string `yaml:"type_field"`
+
+	Create protoreflect.MethodDescriptor `yaml:"-"`
+	List   protoreflect.MethodDescriptor `yaml:"-"`
+	Get    protoreflect.MethodDescriptor `yaml:"-"`
+	Update protoreflect.MethodDescriptor `yaml:"-"`
+	Delete protoreflect.MethodDescriptor `yaml:"-"`
+
+	// MaskField is the google.protobuf.FieldMask field of the Update request, nil when
+	// the backend does not support partial updates and PATCH has to fetch, merge and update
+	MaskField protoreflect.FieldDescriptor `yaml:"-"`
+}
+
+// ResourceMethods names the gRPC methods of a resource, the defaults are
+// Create, GetAll, GetById, Update and Delete
+type ResourceMethods struct {
+	Create string `yaml:"create"`
+	List   string `yaml:"list"`
+	Get    string `yaml:"get"`
+	Update string `yaml:"update"`
+	Delete string `yaml:"delete"`
+}
+
+func (res *Resource) resolve(files *protoregistry.Files) error {
+	if res.IDField == "" {
+		res.IDField = "id"
+	}
+	if res.Since == "" {
+		res.Since = restVersion
+	}
+	if res.TotalField == "" {
+		res.TotalField = "count"
+	}
+	if res.MaxPageSize == 0 {
+		res.MaxPageSize = defaultMaxPageSize
+	}
+
+	methods := []struct {
+		name, fallback string
+		md             *protoreflect.MethodDescriptor
+	}{
+		{res.Methods.Create, "Create", &res.Create},
+		{res.Methods.List, "GetAll", &res.List},
+		{res.Methods.Get, "GetById", &res.Get},
+		{res.Methods.Update, "Update", &res.Update},
+		{res.Methods.Delete, "Delete", &res.Delete},
+	}
+
+	for _, m := range methods {
+		name := m.name
+		if name == "" {
+			name = m.fallback
+		}
+
+		d, err := files.FindDescriptorByName(protoreflect.FullName(res.Service + "." + name))
+		if err != nil {
+			return fmt.Errorf("resource %s: %w", res.Name, err)
+		}
+		md, ok := d.(protoreflect.MethodDescriptor)
+		if !ok {
+			return fmt.Errorf("resource %s: %s is not a method", res.Name, d.FullName())
+		}
+		*m.md = md
+	}
+
+	for _, md := range []protoreflect.MethodDescriptor{res.Get, res.Update, res.Delete} {
+		if _, err := findField(md.Input(), res.IDField); err != nil {
+			return fmt.Errorf("resource %s: %w", res.Name, err)
+		}
+	}
+	if _, err := findField(res.Get.Output(), res.IDField); err != nil {
+		return fmt.Errorf("resource %s: %w", res.Name, err)
+	}
+
+	if err := res.resolvePage(); err != nil {
+		return fmt.Errorf("resource %s: %w", res.Name, err)
+	}
+
+	fields := res.Update.Input().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if fd := fields.Get(i); fd.Message() != nil && fd.Message().FullName() == fieldMaskName && !fd.IsList() {
+			res.MaskField = fd
+		}
+	}
+	if res.TypeField != "" {
+		for _, md := range []protoreflect.MessageDescriptor{res.Create.Input(), res.Get.Output()} {
+			fds, err := findField(md, res.TypeField)
+			if err != nil {
+				return fmt.Errorf("resource %s: type_field: %w", res.Name, err)
+			}
+			if fd := fds[len(fds)-1]; fd.Kind() != protoreflect.StringKind || fd.IsList() {
+				return fmt.Errorf("resource %s: type_field %s is not a string", res.Name, res.TypeField)
+			}
+		}
+	}
+	for _, path := range res.Mutable {
+		if _, err := findField(res.Update.Input(), path); err != nil {
+			return fmt.Errorf("resource %s: mutable: %w", res.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (res *Resource) resolvePage() error {
+	output := res.List.Output()
+	if res.ItemsField == "" {
+		fields := output.Fields()
+		for i := 0; i < fields.Len() && res.ItemsField == ""; i++ {
+			if fd := fields.Get(i); fd.IsList() && fd.Message() != nil {
+				res.ItemsField = string(fd.Name())
+			}
+		}
+	}
+
+	items, err := findField(output, res.ItemsField)
+	if err != nil {
+		return fmt.Errorf("items_field: %w", err)
+	}
+	if !items[len(items)-1].IsList() {
+		return fmt.Errorf("items_field %s is not repeated", res.ItemsField)
+	}
+	if res.Sort != nil {
+		if items[len(items)-1].Message() == nil {
+			return fmt.Errorf("sort: items_field %s is not a message list", res.ItemsField)
+		}
+		if err := res.Sort.resolve(res.List.Input(), items[len(items)-1].Message()); err != nil {
+			return err
+		}
+	}
+
+	total, err := findField(output, res.TotalField)
+	if err != nil {
+		return fmt.Errorf("total_field: %w", err)
+	}
+	switch total[len(total)-1].Kind() {
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind, protoreflect.Sint64Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind:
+	default:
+		return fmt.Errorf("total_field %s is not a signed integer", res.TotalField)
+	}
+
+	for _, f := range paginationFilters {
+		if _, err := findField(res.List.Input(), f.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Page returns the items and the total count of a List response
+func (res *Resource) Page(resp proto.Message) ([]interface{}, int64) {
+	msg := resp.ProtoReflect()
+	fds, _ := findField(msg.Descriptor(), res.ItemsField)
+
+	list := GetField(msg, res.ItemsField).List()
+	items := make([]interface{}, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		items = append(items, valueInterface(fds[len(fds)-1], list.Get(i)))
+	}
+
+	return items, GetField(msg, res.TotalField).Int()
+}
+
+// PageMessage returns a copy of resp, the response of the list method, with items as its items
+func (res *Resource) PageMessage(resp proto.Message, items []interface{}) proto.Message {
+	page := proto.Clone(resp)
+	msg := page.ProtoReflect()
+	fds, _ := findField(msg.Descriptor(), res.ItemsField)
+	for _, fd := range fds[:len(fds)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+
+	fd := fds[len(fds)-1]
+	list := msg.NewField(fd).List()
+	for _, item := range items {
+		list.Append(protoreflect.ValueOfMessage(item.(proto.Message).ProtoReflect()))
+	}
+	msg.Set(fd, protoreflect.ValueOfList(list))
+
+	return page
+}
+
+// Immutable returns the paths of mask the resource does not allow to change
+func (res *Resource) Immutable(mask []string) []string {
+	var immutable []string
+	for _, path := range mask {
+		if !res.isMutable(path) {
+			immutable = append(immutable, path)
+		}
+	}
+
+	return immutable
+}
+
+// RestrictsUpdates reports whether some fields besides the id can not be changed, an update
+// then has to be compared with the current resource, see Changed
+func (res *Resource) RestrictsUpdates() bool {
+	return len(res.Mutable) > 0
+}
+
+// Changed returns the fields of req, an Update request, that differ from current, a response
+// of the Get method; the id and the update mask are left out. A PUT replaces the resource, so
+// its changes are checked with Immutable like the paths of a PATCH.
+func (res *Resource) Changed(current, req proto.Message) ([]string, error) {
+	before, err := res.RestoreRequest(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	a, b := before.ProtoReflect(), req.ProtoReflect()
+	fields := b.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if string(fd.Name()) == res.IDField || fd == res.MaskField {
+			continue
+		}
+		if !equalField(a, b, fd) {
+			changed = append(changed, string(fd.Name()))
+		}
+	}
+
+	return changed, nil
+}
+
+// equalField reports whether the field fd of a and b has the same value
+func equalField(a, b protoreflect.Message, fd protoreflect.FieldDescriptor) bool {
+	x, y := a.New(), b.New()
+	if a.Has(fd) {
+		x.Set(fd, a.Get(fd))
+	}
+	if b.Has(fd) {
+		y.Set(fd, b.Get(fd))
+	}
+
+	return proto.Equal(x.Interface(), y.Interface())
+}
+
+func (res *Resource) isMutable(path string) bool {
+	top := strings.SplitN(path, ".", 2)[0]
+	if len(res.Mutable) == 0 {
+		return top != res.IDField && (res.MaskField == nil || top != string(res.MaskField.Name()))
+	}
+
+	for _, mutable := range res.Mutable {
+		if path == mutable || strings.HasPrefix(path, mutable+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rules returns the RESTful rules of the resource, resolved by Parse like any other rule
+func (res *Resource) rules() []Rule {
+	collection := "/" + res.Name
+	item := collection + "/{" + res.IDField + "}"
+
+	params := []string{ParamCursor}
+	if res.Sort != nil {
+		params = append(params, ParamSort)
+	}
+	var getParams []string
+	if len(res.Expand) > 0 {
+		params = append(params, ParamExpand)
+		getParams = append(getParams, ParamExpand)
+	}
+
+	rules := []Rule{
+		{Selector: string(res.Create.FullName()), Post: collection, Body: "*", Status: http.StatusCreated, action: ActionCreate},
+		{Selector: string(res.List.FullName()), Get: collection, Defaults: map[string]string{"limit": "10", "offset": "0"},
+			Filters: append(append([]Filter(nil), paginationFilters...), res.Filters...), params: params, action: ActionList},
+		{Selector: string(res.Get.FullName()), Get: item, params: getParams, action: ActionGet},
+		{Selector: string(res.Update.FullName()), Put: item, Body: "*", action: ActionUpdate},
+		{Selector: string(res.Update.FullName()), Patch: item, Body: "*", action: ActionPatch},
+		{Selector: string(res.Delete.FullName()), Delete: item, Status: http.StatusNoContent, action: ActionDelete},
+	}
+	for i := range rules {
+		rules[i].Since, rules[i].Until = res.Since, res.Until
+		if rules[i].Body != "" {
+			rules[i].MaxBodySize = res.MaxBodySize
+		}
+	}
+
+	return rules
+}
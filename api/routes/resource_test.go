@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xfirdavs/api_gateway/genproto/position_service"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestImmutableChanges(t *testing.T) {
+	api, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := map[string]*Resource{}
+	for _, res := range api.Resources {
+		resources[res.Name] = res
+	}
+
+	attribute := &position_service.GetByIdAttributeResponse{Id: "1", Name: "level", Type: "integer"}
+	position := &position_service.Position{
+		Id: "1", Name: "a", ProfessionId: "p1", CompanyId: "c1",
+		PositionAttributes: []*position_service.GetPositionAttributes{{Id: "pa1", AttributeId: "x", Value: "1", AttributeName: "level"}},
+	}
+
+	tests := []struct {
+		name     string
+		resource string
+		current  proto.Message
+		req      proto.Message
+		// changed are the fields that differ in field order, immutable those of them the resource keeps
+		changed   []string
+		immutable []string
+	}{
+		{
+			name:     "mutable field",
+			resource: "attribute",
+			current:  attribute,
+			req:      &position_service.UpdateAttributeRequest{Id: "1", Name: "grade", Type: "integer"},
+			changed:  []string{"name"},
+		},
+		{
+			name:      "immutable field",
+			resource:  "attribute",
+			current:   attribute,
+			req:       &position_service.UpdateAttributeRequest{Id: "1", Name: "level", Type: "string"},
+			changed:   []string{"type"},
+			immutable: []string{"type"},
+		},
+		{
+			name:      "immutable field cleared",
+			resource:  "attribute",
+			current:   attribute,
+			req:       &position_service.UpdateAttributeRequest{Id: "1", Name: "grade"},
+			changed:   []string{"name", "type"},
+			immutable: []string{"type"},
+		},
+		{
+			name:     "unchanged",
+			resource: "attribute",
+			current:  attribute,
+			req:      &position_service.UpdateAttributeRequest{Id: "1", Name: "level", Type: "integer"},
+		},
+		{
+			name:     "id is left out",
+			resource: "attribute",
+			current:  attribute,
+			req:      &position_service.UpdateAttributeRequest{Id: "2", Name: "level", Type: "integer"},
+		},
+		{
+			name:     "repeated field compared by the fields of the update",
+			resource: "position",
+			current:  position,
+			req: &position_service.UpdatePositionRequest{Id: "1", Name: "a", ProfessionId: "p1", CompanyId: "c1",
+				PositionAttributes: []*position_service.PositionAttributes{{AttributeId: "x", Value: "1"}}},
+		},
+		{
+			name:     "every field mutable",
+			resource: "position",
+			current:  position,
+			req:      &position_service.UpdatePositionRequest{Id: "1", Name: "b", ProfessionId: "p1", CompanyId: "c2"},
+			changed:  []string{"name", "company_id", "position_attributes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := resources[tt.resource]
+			changed, err := res.Changed(tt.current, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(changed) != len(tt.changed) || len(changed) > 0 && !reflect.DeepEqual(changed, tt.changed) {
+				t.Errorf("changed %v, want %v", changed, tt.changed)
+			}
+			if immutable := res.Immutable(changed); !reflect.DeepEqual(immutable, tt.immutable) {
+				t.Errorf("immutable %v, want %v", immutable, tt.immutable)
+			}
+		})
+	}
+
+	if !resources["attribute"].RestrictsUpdates() || resources["position"].RestrictsUpdates() {
+		t.Error("only the attribute restricts updates")
+	}
+}
@@ -0,0 +1,530 @@
+package routes
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xfirdavs/api_gateway/api/versioning"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed routes.yaml
+var defaultTable []byte
+
+// Table is the declarative route configuration, see routes.yaml.
+// Rules without an HTTP binding only set gateway options of the routes
+// generated from the google.api.http annotations of the same method.
+type Table struct {
+	Versions       []*versioning.Version `yaml:"versions"`
+	DefaultVersion string                `yaml:"default_version"`
+	Routes         []Rule                `yaml:"routes"`
+	Resources      []*Resource           `yaml:"resources"`
+
+	// Validation has the field rules of request messages by message and field name
+	Validation map[string]map[string]*FieldRule `yaml:"validation"`
+}
+
+// Rule maps one HTTP method and path template to a gRPC method.
+// The binding fields follow the google.api.http rule syntax.
+type Rule struct {
+	Selector string `yaml:"selector"`
+
+	Get    string `yaml:"get"`
+	Put    string `yaml:"put"`
+	Post   string `yaml:"post"`
+	Delete string `yaml:"delete"`
+	Patch  string `yaml:"patch"`
+
+	Custom *CustomPattern `yaml:"custom"`
+
+	Body         string `yaml:"body"`
+	ResponseBody string `yaml:"response_body"`
+
+	Tag        string            `yaml:"tag"`
+	Status     int               `yaml:"status"`
+	Defaults   map[string]string `yaml:"defaults"`
+	Middleware []string          `yaml:"middleware"`
+	Filters    []Filter          `yaml:"filters"`
+
+	// Since and Until bound the versions a path without version prefix is served in
+	Since string `yaml:"since"`
+	Until string `yaml:"until"`
+
+	// MaxBodySize limits the request body in bytes and DiscardUnknown drops unknown
+	// body fields instead of rejecting them, both default to the gateway config
+	MaxBodySize    int64 `yaml:"max_body_size"`
+	DiscardUnknown *bool `yaml:"discard_unknown"`
+
+	resource *Resource
+	action   string
+	params   []string
+}
+
+// CustomPattern binds an HTTP method that has no field of its own in Rule, e.g. HEAD
+type CustomPattern struct {
+	Kind string `yaml:"kind"`
+	Path string `yaml:"path"`
+}
+
+// Route is a Rule resolved against the registered protobuf descriptors
+type Route struct {
+	Selector     string
+	Method       string
+	Path         string
+	Tag          string
+	Status       int
+	Body         string
+	ResponseBody string
+	Defaults     map[string]string
+	Middleware   []string
+	Filters      []Filter
+	Version      string
+
+	MaxBodySize    int64
+	DiscardUnknown bool
+
+	// bodyOptions records which of MaxBodySize and DiscardUnknown the rule sets
+	bodyOptions struct{ size, discard bool }
+
+	// Params are query parameters handled by the gateway instead of being bound to the request
+	Params []string
+
+	// Resource and Action are set on the RESTful routes of a resource
+	Resource *Resource
+	Action   string
+
+	// Validation checks the request before the call, nil when no rule applies to it
+	Validation *Validation
+
+	// Writes is the resource whose Create or Update method the route calls, its
+	// references and attribute type are checked before the call
+	Writes *Resource
+
+	// Modifies is the resource whose Update or Delete method the route calls, If-Match
+	// is checked against the ETag of its current version
+	Modifies *Resource
+
+	// MaxPageSize bounds the limit of a route calling the List method of a resource
+	// besides its RESTful list route, which checks the limit itself; 0 when unbounded
+	MaxPageSize int
+
+	RPC    protoreflect.MethodDescriptor
+	Input  protoreflect.MessageType
+	Output protoreflect.MessageType
+
+	template *template
+}
+
+// API is the parsed route table
+type API struct {
+	Routes     []*Route
+	Resources  []*Resource
+	Batches    []*Batch
+	Versions   *versioning.Versions
+	Validation *Validation
+	// Encoder is the JSON encoding of the responses, documented in the swagger definitions
+	Encoder Encoder
+}
+
+// Load builds the routes from the google.api.http annotations of the registered
+// services and the route table at path, the built-in table is used when path is empty
+func Load(path string) (*API, error) {
+	data := defaultTable
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return Parse(data, protoregistry.GlobalFiles, protoregistry.GlobalTypes)
+}
+
+// Parse parses a YAML route table, merges it with the annotated rules of files
+// and resolves every rule with files and types. A rule whose path has no version
+// prefix gets one route per version in its range.
+func Parse(data []byte, files *protoregistry.Files, types *protoregistry.Types) (*API, error) {
+	var table Table
+	if err := yaml.UnmarshalStrict(data, &table); err != nil {
+		return nil, err
+	}
+
+	rules := annotatedRules(files)
+	options := map[string]Rule{}
+	for _, rule := range table.Routes {
+		if rule.hasBinding() {
+			rules = append(rules, rule)
+			continue
+		}
+		if _, ok := options[rule.Selector]; ok {
+			return nil, fmt.Errorf("route %s: options are set twice", rule.Selector)
+		}
+		options[rule.Selector] = rule
+	}
+
+	used := map[string]bool{}
+	for i := range rules {
+		if opt, ok := options[rules[i].Selector]; ok {
+			rules[i].applyOptions(opt)
+			used[opt.Selector] = true
+		}
+	}
+	for selector := range options {
+		if !used[selector] {
+			return nil, fmt.Errorf("route %s: options for a method without http binding", selector)
+		}
+	}
+
+	for _, res := range table.Resources {
+		if err := res.resolve(files); err != nil {
+			return nil, err
+		}
+	}
+	if err := resolveExpansions(table.Resources); err != nil {
+		return nil, err
+	}
+	if err := resolveReferences(table.Resources); err != nil {
+		return nil, err
+	}
+	for _, res := range table.Resources {
+		for _, rule := range res.rules() {
+			rule.resource = res
+			rules = append(rules, rule)
+		}
+	}
+
+	if len(table.Versions) == 0 {
+		table.Versions = inferVersions(rules)
+	}
+	versions, err := versioning.New(table.Versions, table.DefaultVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]*Route, 0, len(rules))
+	for _, rule := range rules {
+		route, err := Resolve(rule, files, types)
+		if err != nil {
+			return nil, err
+		}
+		versioned, err := route.versioned(rule, versions)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, versioned...)
+	}
+	validation, err := newValidation(table.Validation, files)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		if validation.covers(route.RPC.Input()) {
+			route.Validation = validation
+		}
+		for _, res := range table.Resources {
+			if route.RPC == res.Create || route.RPC == res.Update {
+				route.Writes = res
+			}
+			if route.RPC == res.Update || route.RPC == res.Delete {
+				route.Modifies = res
+			}
+			if route.RPC == res.List && route.Action == "" {
+				route.MaxPageSize = res.MaxPageSize
+			}
+		}
+	}
+
+	return &API{Routes: routes, Resources: table.Resources, Batches: batches(routes, table.Resources), Versions: versions, Validation: validation}, nil
+}
+
+// SetBodyDefaults sets the body size limit and the unknown field policy of the routes
+// whose rule does not set them
+func (api *API) SetBodyDefaults(maxBodySize int64, discardUnknown bool) {
+	for _, route := range api.Routes {
+		if !route.bodyOptions.size && maxBodySize > 0 {
+			route.MaxBodySize = maxBodySize
+		}
+		if !route.bodyOptions.discard {
+			route.DiscardUnknown = discardUnknown
+		}
+	}
+}
+
+// versioned returns the route for every version of the rule's range,
+// a route whose path has a version prefix must lie in the range
+func (r *Route) versioned(rule Rule, versions *versioning.Versions) ([]*Route, error) {
+	names, err := versions.Range(rule.Since, rule.Until)
+	if err != nil {
+		return nil, fmt.Errorf("route %s: %w", r.Selector, err)
+	}
+
+	if version := r.template.version(); version != "" {
+		for _, name := range names {
+			if name == version {
+				r.Version = version
+				return []*Route{r}, nil
+			}
+		}
+		return nil, fmt.Errorf("route %s: version %s of %s is not in the versions of the route", r.Selector, version, r.Path)
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("route %s: no version to serve %s in", r.Selector, r.Path)
+	}
+
+	routes := make([]*Route, 0, len(names))
+	for _, name := range names {
+		route := *r
+		route.Version = name
+		route.Path = "/" + name + r.Path
+		route.template = r.template.withVersion(name)
+		routes = append(routes, &route)
+	}
+
+	return routes, nil
+}
+
+// inferVersions lists the version prefixes used by rules, for route tables without versions,
+// rules without version prefix name their first version with since
+func inferVersions(rules []Rule) []*versioning.Version {
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		paths := []string{rule.Get, rule.Put, rule.Post, rule.Delete, rule.Patch}
+		if rule.Custom != nil {
+			paths = append(paths, rule.Custom.Path)
+		}
+		for _, path := range paths {
+			if first := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]; versioning.IsVersion(first) {
+				seen[first] = true
+			}
+		}
+		if rule.Since != "" {
+			seen[rule.Since] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, _ := strconv.Atoi(strings.TrimPrefix(names[i], "v"))
+		b, _ := strconv.Atoi(strings.TrimPrefix(names[j], "v"))
+		return a < b
+	})
+
+	versions := make([]*versioning.Version, 0, len(names))
+	for _, name := range names {
+		versions = append(versions, &versioning.Version{Name: name})
+	}
+
+	return versions
+}
+
+// Resolve looks up the rule's gRPC method and validates its bindings
+func Resolve(rule Rule, files *protoregistry.Files, types *protoregistry.Types) (*Route, error) {
+	d, err := files.FindDescriptorByName(protoreflect.FullName(rule.Selector))
+	if err != nil {
+		return nil, fmt.Errorf("route %s: %w", rule.Selector, err)
+	}
+	md, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("route %s: not a method", rule.Selector)
+	}
+
+	route := &Route{
+		Selector:     rule.Selector,
+		Tag:          rule.Tag,
+		Status:       rule.Status,
+		Body:         rule.Body,
+		ResponseBody: rule.ResponseBody,
+		Defaults:     rule.Defaults,
+		Middleware:   rule.Middleware,
+		Filters:      append([]Filter(nil), rule.Filters...),
+		Params:       append([]string{ParamFields}, rule.params...),
+		Resource:     rule.resource,
+		Action:       rule.action,
+		RPC:          md,
+		Input:        messageType(md.Input(), types),
+		Output:       messageType(md.Output(), types),
+		MaxBodySize:  rule.MaxBodySize,
+	}
+	if route.MaxBodySize == 0 {
+		route.MaxBodySize = defaultMaxBodySize
+	} else {
+		route.bodyOptions.size = true
+	}
+	if rule.DiscardUnknown != nil {
+		route.DiscardUnknown = *rule.DiscardUnknown
+		route.bodyOptions.discard = true
+	}
+
+	bindings := map[string]string{
+		http.MethodGet:    rule.Get,
+		http.MethodPut:    rule.Put,
+		http.MethodPost:   rule.Post,
+		http.MethodDelete: rule.Delete,
+		http.MethodPatch:  rule.Patch,
+	}
+	if rule.Custom != nil {
+		bindings[strings.ToUpper(rule.Custom.Kind)] = rule.Custom.Path
+	}
+	for method, path := range bindings {
+		if path == "" {
+			continue
+		}
+		if route.Method != "" {
+			return nil, fmt.Errorf("route %s: more than one HTTP method", rule.Selector)
+		}
+		route.Method, route.Path = method, path
+	}
+	if route.Method == "" {
+		return nil, fmt.Errorf("route %s: HTTP method is not set", rule.Selector)
+	}
+
+	if route.template, err = parseTemplate(route.Path); err != nil {
+		return nil, fmt.Errorf("route %s: %w", rule.Selector, err)
+	}
+	for _, s := range route.template.segments {
+		if s.field == "" {
+			continue
+		}
+		if _, err := findField(md.Input(), s.field); err != nil {
+			return nil, fmt.Errorf("route %s: path %s: %w", rule.Selector, route.Path, err)
+		}
+	}
+
+	if route.Body != "" && route.Body != "*" {
+		if _, err := findField(md.Input(), route.Body); err != nil {
+			return nil, fmt.Errorf("route %s: body: %w", rule.Selector, err)
+		}
+	}
+	if route.ResponseBody != "" {
+		if _, err := findField(md.Output(), route.ResponseBody); err != nil {
+			return nil, fmt.Errorf("route %s: response_body: %w", rule.Selector, err)
+		}
+	}
+	for field := range route.Defaults {
+		if _, err := findField(md.Input(), field); err != nil {
+			return nil, fmt.Errorf("route %s: defaults: %w", rule.Selector, err)
+		}
+	}
+	for i := range route.Filters {
+		if err := route.Filters[i].resolve(md.Input()); err != nil {
+			return nil, fmt.Errorf("route %s: %w", rule.Selector, err)
+		}
+	}
+
+	if route.Status == 0 {
+		route.Status = http.StatusOK
+	}
+	if route.Tag == "" {
+		route.Tag = route.template.tag()
+	}
+
+	return route, nil
+}
+
+func (r Rule) hasBinding() bool {
+	return r.Get != "" || r.Put != "" || r.Post != "" || r.Delete != "" || r.Patch != "" || r.Custom != nil
+}
+
+// applyOptions copies the gateway options that are set in opt
+func (r *Rule) applyOptions(opt Rule) {
+	if opt.Tag != "" {
+		r.Tag = opt.Tag
+	}
+	if opt.Status != 0 {
+		r.Status = opt.Status
+	}
+	if opt.Defaults != nil {
+		r.Defaults = opt.Defaults
+	}
+	if opt.Middleware != nil {
+		r.Middleware = opt.Middleware
+	}
+	if opt.Filters != nil {
+		r.Filters = opt.Filters
+	}
+	if opt.Since != "" {
+		r.Since = opt.Since
+	}
+	if opt.Until != "" {
+		r.Until = opt.Until
+	}
+	if opt.MaxBodySize != 0 {
+		r.MaxBodySize = opt.MaxBodySize
+	}
+	if opt.DiscardUnknown != nil {
+		r.DiscardUnknown = opt.DiscardUnknown
+	}
+}
+
+// GinPath returns the path in gin syntax, e.g. /v1/company/:id
+func (r *Route) GinPath() string {
+	return r.template.ginPath()
+}
+
+// Service returns the full name of the gRPC service
+func (r *Route) Service() string {
+	return string(r.RPC.Parent().FullName())
+}
+
+// FullMethod returns the gRPC method name used on the wire, e.g. /company_service.CompanyService/Create
+func (r *Route) FullMethod() string {
+	return FullMethod(r.RPC)
+}
+
+// FullMethod returns the gRPC method name of md used on the wire
+func FullMethod(md protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+}
+
+// NewMessage returns a new message of md, using the generated type when it is linked in
+func NewMessage(md protoreflect.MessageDescriptor) proto.Message {
+	return messageType(md, protoregistry.GlobalTypes).New().Interface()
+}
+
+func messageType(md protoreflect.MessageDescriptor, types *protoregistry.Types) protoreflect.MessageType {
+	if mt, err := types.FindMessageByName(md.FullName()); err == nil {
+		return mt
+	}
+
+	return dynamicpb.NewMessageType(md)
+}
+
+// findField resolves a dotted field path, e.g. "company.id", accepting proto and JSON names
+func findField(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	names := strings.Split(path, ".")
+	fds := make([]protoreflect.FieldDescriptor, 0, len(names))
+
+	for i, name := range names {
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, fmt.Errorf("unknown field %q in %s", path, md.FullName())
+		}
+		fds = append(fds, fd)
+
+		if i < len(names)-1 {
+			if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return nil, fmt.Errorf("field %q in %s is not a message", name, md.FullName())
+			}
+			md = fd.Message()
+		}
+	}
+
+	return fds, nil
+}
@@ -0,0 +1,199 @@
+package routes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ParamSort is the query parameter of the list routes ordering the items, e.g. sort=name,-created_at
+const ParamSort = "sort"
+
+// defaultSortMaxItems bounds the lists sorted by the gateway when the resource sets no max_items
+const defaultSortMaxItems = 1000
+
+// Sort is the ordering of a resource list. Backends taking the sort expression get it
+// in Field, other lists are sorted by the gateway as long as they have at most MaxItems items.
+type Sort struct {
+	Fields   []string `yaml:"fields"`
+	Field    string   `yaml:"field"`
+	MaxItems int      `yaml:"max_items"`
+
+	item   protoreflect.MessageDescriptor
+	fields map[string][]protoreflect.FieldDescriptor
+}
+
+// SortKey is one field of a sort expression
+type SortKey struct {
+	Field string
+	Desc  bool
+
+	fds []protoreflect.FieldDescriptor
+}
+
+func (s *Sort) resolve(input, item protoreflect.MessageDescriptor) error {
+	if s.MaxItems == 0 {
+		s.MaxItems = defaultSortMaxItems
+	}
+
+	s.item = item
+	s.fields = map[string][]protoreflect.FieldDescriptor{}
+	for i, name := range s.Fields {
+		fds, err := findField(item, name)
+		if err != nil {
+			return fmt.Errorf("sort: %w", err)
+		}
+		if fd := fds[len(fds)-1]; fd.Message() != nil || fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("sort: field %s is not a scalar", name)
+		}
+		s.Fields[i] = protoPath(fds)
+		s.fields[s.Fields[i]] = fds
+	}
+
+	if s.Field != "" {
+		fds, err := findField(input, s.Field)
+		if err != nil {
+			return fmt.Errorf("sort: %w", err)
+		}
+		if fds[len(fds)-1].Kind() != protoreflect.StringKind {
+			return fmt.Errorf("sort: field %s is not a string", s.Field)
+		}
+	}
+
+	return nil
+}
+
+// Parse parses a sort expression such as "name,-created_at", every field must be in Fields
+func (s *Sort) Parse(expr string) ([]SortKey, error) {
+	var keys []SortKey
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		key := SortKey{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+
+		fds, err := findField(s.item, key.Field)
+		if err == nil {
+			key.Field = protoPath(fds)
+		}
+		if key.fds = s.fields[key.Field]; key.fds == nil {
+			return nil, fmt.Errorf("can not sort by %q, sortable fields: %s", part, strings.Join(s.Fields, ", "))
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("%s is sorted by twice", key.Field)
+		}
+		seen[key.Field] = true
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Forward sets the sort expression in the request field of a backend that sorts itself
+func (s *Sort) Forward(msg protoreflect.Message, keys []SortKey) error {
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key.Desc {
+			values = append(values, "-"+key.Field)
+		} else {
+			values = append(values, key.Field)
+		}
+	}
+
+	fds, _ := findField(msg.Descriptor(), s.Field)
+	if fds[len(fds)-1].IsList() {
+		return SetField(msg, s.Field, values)
+	}
+
+	return SetField(msg, s.Field, []string{strings.Join(values, ",")})
+}
+
+// Apply sorts items, proto messages of the list item type, by keys
+func (s *Sort) Apply(items []interface{}, keys []SortKey) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].(proto.Message).ProtoReflect(), items[j].(proto.Message).ProtoReflect()
+		for _, key := range keys {
+			c := compareValues(key.fds[len(key.fds)-1], fieldValue(a, key.fds), fieldValue(b, key.fds))
+			if c == 0 {
+				continue
+			}
+			if key.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+func fieldValue(msg protoreflect.Message, fds []protoreflect.FieldDescriptor) protoreflect.Value {
+	for _, fd := range fds[:len(fds)-1] {
+		msg = msg.Get(fd).Message()
+	}
+
+	return msg.Get(fds[len(fds)-1])
+}
+
+func compareValues(fd protoreflect.FieldDescriptor, a, b protoreflect.Value) int {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return strings.Compare(a.String(), b.String())
+	case protoreflect.BytesKind:
+		return strings.Compare(string(a.Bytes()), string(b.Bytes()))
+	case protoreflect.BoolKind:
+		return compareInt(boolInt(a.Bool()), boolInt(b.Bool()))
+	case protoreflect.EnumKind:
+		return compareInt(int64(a.Enum()), int64(b.Enum()))
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		switch x, y := a.Float(), b.Float(); {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		switch x, y := a.Uint(), b.Uint(); {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+
+	return compareInt(a.Int(), b.Int())
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// protoPath joins the proto names of a resolved field path
+func protoPath(fds []protoreflect.FieldDescriptor) string {
+	names := make([]string, 0, len(fds))
+	for _, fd := range fds {
+		names = append(names, string(fd.Name()))
+	}
+
+	return strings.Join(names, ".")
+}
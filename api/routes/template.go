@@ -0,0 +1,128 @@
+package routes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xfirdavs/api_gateway/api/versioning"
+)
+
+// template is a parsed path template such as /v1/company/{id}.
+// Variables may be written as {field}, {field=*} or, in the last segment, {field=**}.
+type template struct {
+	segments []segment
+}
+
+type segment struct {
+	literal  string
+	field    string
+	wildcard bool
+}
+
+func parseTemplate(path string) (*template, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path template %q must start with /", path)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	t := &template{segments: make([]segment, 0, len(parts))}
+
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "{") {
+			if part == "" || strings.ContainsAny(part, "{}:*") {
+				return nil, fmt.Errorf("path template %q: invalid segment %q", path, part)
+			}
+			t.segments = append(t.segments, segment{literal: part})
+			continue
+		}
+
+		if !strings.HasSuffix(part, "}") {
+			return nil, fmt.Errorf("path template %q: unterminated variable %q", path, part)
+		}
+
+		field, pattern := strings.Trim(part, "{}"), "*"
+		if idx := strings.Index(field, "="); idx >= 0 {
+			field, pattern = field[:idx], field[idx+1:]
+		}
+
+		s := segment{field: field}
+		switch pattern {
+		case "*":
+		case "**":
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("path template %q: ** is only allowed in the last segment", path)
+			}
+			s.wildcard = true
+		default:
+			return nil, fmt.Errorf("path template %q: unsupported pattern %q", path, pattern)
+		}
+		t.segments = append(t.segments, s)
+	}
+
+	return t, nil
+}
+
+// param returns the gin parameter name of a variable segment
+func (s segment) param() string {
+	return strings.ReplaceAll(s.field, ".", "_")
+}
+
+func (t *template) ginPath() string {
+	var b strings.Builder
+	for _, s := range t.segments {
+		b.WriteString("/")
+		switch {
+		case s.field == "":
+			b.WriteString(s.literal)
+		case s.wildcard:
+			b.WriteString("*" + s.param())
+		default:
+			b.WriteString(":" + s.param())
+		}
+	}
+
+	return b.String()
+}
+
+// swaggerPath returns the path in OpenAPI syntax, e.g. /v1/company/{id}
+func (t *template) swaggerPath() string {
+	var b strings.Builder
+	for _, s := range t.segments {
+		b.WriteString("/")
+		if s.field == "" {
+			b.WriteString(s.literal)
+		} else {
+			b.WriteString("{" + s.param() + "}")
+		}
+	}
+
+	return b.String()
+}
+
+// tag returns the first literal segment that is not a version, e.g. company for /v1/company/{id}
+func (t *template) tag() string {
+	for _, s := range t.segments {
+		if s.field == "" && !versioning.IsVersion(s.literal) {
+			return s.literal
+		}
+	}
+
+	return ""
+}
+
+// version returns the version prefix of the template, e.g. v1 for /v1/company/{id}
+func (t *template) version() string {
+	if len(t.segments) > 0 && versioning.IsVersion(t.segments[0].literal) {
+		return t.segments[0].literal
+	}
+
+	return ""
+}
+
+// withVersion returns a copy of the template prefixed with version
+func (t *template) withVersion(version string) *template {
+	segments := make([]segment, 0, len(t.segments)+1)
+	segments = append(segments, segment{literal: version})
+
+	return &template{segments: append(segments, t.segments...)}
+}
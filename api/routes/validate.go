@@ -0,0 +1,442 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-openapi/spec"
+	"github.com/xfirdavs/api_gateway/pkg/attrtype"
+	"github.com/xfirdavs/api_gateway/pkg/util"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// FieldRule constrains a field of a request message. Length, pattern, format and enum
+// rules apply to every element of a repeated field, the item counts to the list.
+type FieldRule struct {
+	Required bool     `yaml:"required"`
+	MinLen   *int     `yaml:"min_len"`
+	MaxLen   *int     `yaml:"max_len"`
+	Min      *float64 `yaml:"min"`
+	Max      *float64 `yaml:"max"`
+	MinItems *int     `yaml:"min_items"`
+	MaxItems *int     `yaml:"max_items"`
+	Pattern  string   `yaml:"pattern"`
+	// Format is uuid or an attribute type without argument, e.g. email, phone, url or date
+	Format string   `yaml:"format"`
+	Enum   []string `yaml:"enum"`
+
+	fd      protoreflect.FieldDescriptor
+	pattern *regexp.Regexp
+	format  attrtype.Validator
+}
+
+// Violation is a field of a request that breaks a rule
+type Violation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// ValidationError lists every violation of a request
+type ValidationError []Violation
+
+func (e ValidationError) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, v := range e {
+		parts = append(parts, v.Field+": "+v.Description)
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// Validation holds the field rules of the request messages by message name. The rules
+// of a message also apply where it is nested in another request.
+type Validation struct {
+	messages map[protoreflect.FullName]map[string]*FieldRule
+	covered  map[protoreflect.FullName]bool
+}
+
+func newValidation(rules map[string]map[string]*FieldRule, files *protoregistry.Files) (*Validation, error) {
+	v := &Validation{
+		messages: map[protoreflect.FullName]map[string]*FieldRule{},
+		covered:  map[protoreflect.FullName]bool{},
+	}
+
+	for name, fields := range rules {
+		d, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("validation %s: %w", name, err)
+		}
+		md, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("validation %s: not a message", name)
+		}
+
+		for field, rule := range fields {
+			if err := rule.resolve(md, field); err != nil {
+				return nil, fmt.Errorf("validation %s.%s: %w", name, field, err)
+			}
+		}
+		v.messages[md.FullName()] = fields
+	}
+
+	return v, nil
+}
+
+func (rule *FieldRule) resolve(md protoreflect.MessageDescriptor, field string) error {
+	if rule.fd = md.Fields().ByName(protoreflect.Name(field)); rule.fd == nil {
+		return fmt.Errorf("unknown field")
+	}
+	if rule.fd.IsMap() {
+		return fmt.Errorf("map fields can not be validated")
+	}
+
+	isString := rule.fd.Kind() == protoreflect.StringKind
+	isNumber := rule.fd.Message() == nil && !isString && rule.fd.Kind() != protoreflect.BoolKind &&
+		rule.fd.Kind() != protoreflect.BytesKind && rule.fd.Kind() != protoreflect.EnumKind
+	switch {
+	case (rule.MinLen != nil || rule.MaxLen != nil || rule.Pattern != "" || rule.Format != "" || len(rule.Enum) > 0) && !isString:
+		return fmt.Errorf("min_len, max_len, pattern, format and enum need a string field")
+	case (rule.Min != nil || rule.Max != nil) && !isNumber:
+		return fmt.Errorf("min and max need a number field")
+	case (rule.MinItems != nil || rule.MaxItems != nil) && !rule.fd.IsList():
+		return fmt.Errorf("min_items and max_items need a repeated field")
+	}
+
+	if rule.Pattern != "" {
+		var err error
+		if rule.pattern, err = regexp.Compile(rule.Pattern); err != nil {
+			return err
+		}
+	}
+	if rule.Format != "" && rule.Format != FormatUUID {
+		var err error
+		if rule.format, err = attrtype.Parse(rule.Format); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// covers reports whether md or a message nested in it has rules. It is called by Parse
+// for every route input, so that Validate only reads the messages covered.
+func (v *Validation) covers(md protoreflect.MessageDescriptor) bool {
+	if covered, ok := v.covered[md.FullName()]; ok {
+		return covered
+	}
+
+	v.covered[md.FullName()] = false // breaks recursive messages
+	covered := len(v.messages[md.FullName()]) > 0
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if element := elementMessage(fields.Get(i)); element != nil && v.covers(element) {
+			covered = true
+		}
+	}
+	v.covered[md.FullName()] = covered
+
+	return covered
+}
+
+// Validate checks msg against the rules of its message and of the messages nested in it.
+// When paths is not nil only the fields on those paths, e.g. an update mask, are checked.
+func (v *Validation) Validate(msg proto.Message, paths []string) error {
+	var errs ValidationError
+	v.validate(msg.ProtoReflect(), "", paths, &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+func (v *Validation) validate(msg protoreflect.Message, prefix string, paths []string, errs *ValidationError) {
+	md := msg.Descriptor()
+	if !v.covered[md.FullName()] {
+		return
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := string(fd.Name())
+		sub, ok := selectPaths(paths, name)
+		if !ok {
+			continue
+		}
+		path := prefix + name
+
+		if rule := v.messages[md.FullName()][name]; rule != nil {
+			for _, problem := range rule.check(msg, fd) {
+				*errs = append(*errs, Violation{Field: path, Description: problem})
+			}
+		}
+
+		element := elementMessage(fd)
+		if element == nil || !v.covered[element.FullName()] {
+			continue
+		}
+		switch {
+		case fd.IsList():
+			list := msg.Get(fd).List()
+			for j := 0; j < list.Len(); j++ {
+				v.validate(list.Get(j).Message(), fmt.Sprintf("%s[%d].", path, j), sub, errs)
+			}
+		case fd.IsMap():
+			msg.Get(fd).Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+				v.validate(value.Message(), fmt.Sprintf("%s[%s].", path, key.String()), sub, errs)
+				return true
+			})
+		case msg.Has(fd):
+			v.validate(msg.Get(fd).Message(), path+".", sub, errs)
+		}
+	}
+}
+
+// selectPaths reports whether the field name is on one of paths and returns the paths below it,
+// nil paths select every field
+func selectPaths(paths []string, name string) ([]string, bool) {
+	if paths == nil {
+		return nil, true
+	}
+
+	var sub []string
+	selected := false
+	for _, path := range paths {
+		switch {
+		case path == name:
+			return nil, true
+		case strings.HasPrefix(path, name+"."):
+			sub = append(sub, strings.TrimPrefix(path, name+"."))
+			selected = true
+		}
+	}
+
+	return sub, selected
+}
+
+// check returns the problems of the field fd of msg
+func (rule *FieldRule) check(msg protoreflect.Message, fd protoreflect.FieldDescriptor) []string {
+	var problems []string
+
+	if !fd.IsList() {
+		value := msg.Get(fd)
+		if rule.Required && (!msg.Has(fd) || (fd.Kind() == protoreflect.StringKind && strings.TrimSpace(value.String()) == "")) {
+			return []string{"is required"}
+		}
+		if !msg.Has(fd) {
+			return nil
+		}
+		return rule.checkValue(fd, value)
+	}
+
+	list := msg.Get(fd).List()
+	switch {
+	case rule.Required && list.Len() == 0:
+		return []string{"is required"}
+	case rule.MinItems != nil && list.Len() < *rule.MinItems:
+		problems = append(problems, fmt.Sprintf("must have at least %d items", *rule.MinItems))
+	case rule.MaxItems != nil && list.Len() > *rule.MaxItems:
+		problems = append(problems, fmt.Sprintf("must have at most %d items", *rule.MaxItems))
+	}
+	for i := 0; i < list.Len(); i++ {
+		for _, problem := range rule.checkValue(fd, list.Get(i)) {
+			problems = append(problems, fmt.Sprintf("item %d %s", i, problem))
+		}
+	}
+
+	return problems
+}
+
+func (rule *FieldRule) checkValue(fd protoreflect.FieldDescriptor, value protoreflect.Value) []string {
+	var problems []string
+
+	if fd.Kind() == protoreflect.StringKind {
+		s := value.String()
+		n := utf8.RuneCountInString(s)
+		if rule.MinLen != nil && n < *rule.MinLen {
+			problems = append(problems, fmt.Sprintf("must be at least %d characters", *rule.MinLen))
+		}
+		if rule.MaxLen != nil && n > *rule.MaxLen {
+			problems = append(problems, fmt.Sprintf("must be at most %d characters", *rule.MaxLen))
+		}
+		if rule.pattern != nil && !rule.pattern.MatchString(s) {
+			problems = append(problems, "must match "+rule.Pattern)
+		}
+		if rule.Format == FormatUUID && !util.IsValidUUID(s) {
+			problems = append(problems, "must be a UUID")
+		}
+		if rule.format != nil {
+			if err := rule.format(s); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+		if len(rule.Enum) > 0 && !contains(rule.Enum, s) {
+			problems = append(problems, "must be one of "+strings.Join(rule.Enum, ", "))
+		}
+		return problems
+	}
+
+	if rule.Min != nil || rule.Max != nil {
+		var f float64
+		switch fd.Kind() {
+		case protoreflect.FloatKind, protoreflect.DoubleKind:
+			f = value.Float()
+		case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+			f = float64(value.Uint())
+		default:
+			f = float64(value.Int())
+		}
+		if rule.Min != nil && f < *rule.Min {
+			problems = append(problems, fmt.Sprintf("must be at least %v", *rule.Min))
+		}
+		if rule.Max != nil && f > *rule.Max {
+			problems = append(problems, fmt.Sprintf("must be at most %v", *rule.Max))
+		}
+	}
+
+	return problems
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UnknownFields returns the paths of the fields of the JSON document data that md does not have
+func UnknownFields(md protoreflect.MessageDescriptor, data []byte) []string {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+
+	var unknown []string
+	unknownFields(md, doc, "", &unknown)
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+func unknownFields(md protoreflect.MessageDescriptor, doc interface{}, prefix string, unknown *[]string) {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for key, value := range obj {
+		fd := md.Fields().ByName(protoreflect.Name(key))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(key)
+		}
+		if fd == nil {
+			*unknown = append(*unknown, prefix+key)
+			continue
+		}
+
+		element := elementMessage(fd)
+		if element == nil {
+			continue
+		}
+		switch v := value.(type) {
+		case []interface{}:
+			for i, item := range v {
+				unknownFields(element, item, fmt.Sprintf("%s%s[%d].", prefix, key, i), unknown)
+			}
+		case map[string]interface{}:
+			if fd.IsList() {
+				continue // decoding reports the object where a list is expected
+			}
+			if !fd.IsMap() {
+				unknownFields(element, v, prefix+key+".", unknown)
+				continue
+			}
+			for k, item := range v {
+				unknownFields(element, item, fmt.Sprintf("%s%s[%s].", prefix, key, k), unknown)
+			}
+		}
+	}
+}
+
+// UnknownBodyFields returns the paths of the fields of the request body data that the body message does not have
+func (r *Route) UnknownBodyFields(data []byte) []string {
+	md := r.RPC.Input()
+	if r.Body != "*" {
+		fds, _ := findField(md, r.Body)
+		md = fds[len(fds)-1].Message()
+	}
+
+	return UnknownFields(md, data)
+}
+
+// document adds the rules to the schemas of the messages in defs
+func (v *Validation) document(defs spec.Definitions) {
+	for name, fields := range v.messages {
+		schema, ok := defs[string(name)]
+		if !ok {
+			continue
+		}
+
+		for field, rule := range fields {
+			if rule.Required {
+				schema.AddRequired(field)
+			}
+			property := schema.Properties[field]
+			target := &property
+			if rule.fd.IsList() {
+				if rule.MinItems != nil {
+					property.WithMinItems(int64(*rule.MinItems))
+				}
+				if rule.MaxItems != nil {
+					property.WithMaxItems(int64(*rule.MaxItems))
+				}
+				if property.Items == nil || property.Items.Schema == nil {
+					continue
+				}
+				target = property.Items.Schema
+			}
+			rule.document(target)
+			schema.Properties[field] = property
+		}
+		defs[string(name)] = schema
+	}
+}
+
+func (rule *FieldRule) document(s *spec.Schema) {
+	if rule.MinLen != nil {
+		s.WithMinLength(int64(*rule.MinLen))
+	}
+	if rule.MaxLen != nil {
+		s.WithMaxLength(int64(*rule.MaxLen))
+	}
+	if rule.Min != nil {
+		s.WithMinimum(*rule.Min, false)
+	}
+	if rule.Max != nil {
+		s.WithMaximum(*rule.Max, false)
+	}
+	if rule.Pattern != "" {
+		s.WithPattern(rule.Pattern)
+	}
+	if rule.Format != "" {
+		s.Format = rule.Format
+	}
+	if len(rule.Enum) > 0 {
+		values := make([]interface{}, 0, len(rule.Enum))
+		for _, value := range rule.Enum {
+			values = append(values, value)
+		}
+		s.WithEnum(values...)
+	}
+}
@@ -0,0 +1,235 @@
+package versioning
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xfirdavs/api_gateway/api/models"
+	"github.com/xfirdavs/api_gateway/pkg/metrics"
+)
+
+// HeaderAcceptVersion selects the version of a request whose path has no version prefix
+const HeaderAcceptVersion = "Accept-Version"
+
+const dateLayout = "2006-01-02"
+
+var (
+	versionName = regexp.MustCompile(`^v[0-9]+$`)
+
+	requestsTotal = metrics.NewCounter(
+		"api_version_requests_total",
+		"Requests served per API version, deprecated versions are labelled",
+		"version", "deprecated",
+	)
+)
+
+// Version is one API version, e.g. v1
+type Version struct {
+	Name       string `yaml:"name"`
+	Deprecated string `yaml:"deprecated"` // date the version was deprecated, YYYY-MM-DD
+	Sunset     string `yaml:"sunset"`     // date the version stops being served, YYYY-MM-DD
+	Link       string `yaml:"link"`       // migration guide sent with the Deprecation header
+
+	deprecatedAt time.Time
+	sunsetAt     time.Time
+}
+
+// IsDeprecated reports whether the version has a deprecation date
+func (v *Version) IsDeprecated() bool {
+	return !v.deprecatedAt.IsZero()
+}
+
+// Versions is the ordered list of supported versions, oldest first. A path with a version
+// prefix, /v1/..., is served in that version only; Handler serves a path without one in the
+// version of the Accept-Version header or Default.
+type Versions struct {
+	list    []*Version
+	byName  map[string]*Version
+	Default string
+}
+
+// New validates versions, defaultVersion is used for requests without a version
+// and falls back to the newest version when empty
+func New(versions []*Version, defaultVersion string) (*Versions, error) {
+	v := &Versions{
+		list:    versions,
+		byName:  map[string]*Version{},
+		Default: defaultVersion,
+	}
+
+	for _, version := range versions {
+		if !versionName.MatchString(version.Name) {
+			return nil, fmt.Errorf("version %q: name must look like v1", version.Name)
+		}
+		if _, ok := v.byName[version.Name]; ok {
+			return nil, fmt.Errorf("version %s is defined twice", version.Name)
+		}
+
+		var err error
+		if version.Deprecated != "" {
+			if version.deprecatedAt, err = time.Parse(dateLayout, version.Deprecated); err != nil {
+				return nil, fmt.Errorf("version %s: deprecated: %w", version.Name, err)
+			}
+		}
+		if version.Sunset != "" {
+			if version.sunsetAt, err = time.Parse(dateLayout, version.Sunset); err != nil {
+				return nil, fmt.Errorf("version %s: sunset: %w", version.Name, err)
+			}
+		}
+
+		v.byName[version.Name] = version
+	}
+
+	if v.Default == "" && len(versions) > 0 {
+		v.Default = versions[len(versions)-1].Name
+	}
+	if _, ok := v.byName[v.Default]; !ok && v.Default != "" {
+		return nil, fmt.Errorf("default version %s is not defined", v.Default)
+	}
+
+	return v, nil
+}
+
+// IsVersion reports whether segment looks like a version path segment
+func IsVersion(segment string) bool {
+	return versionName.MatchString(segment)
+}
+
+// Get returns the version called name or nil
+func (v *Versions) Get(name string) *Version {
+	return v.byName[name]
+}
+
+// Names returns the version names, oldest first
+func (v *Versions) Names() []string {
+	names := make([]string, 0, len(v.list))
+	for _, version := range v.list {
+		names = append(names, version.Name)
+	}
+
+	return names
+}
+
+// Range returns the versions from since to until inclusive, empty bounds are open
+func (v *Versions) Range(since, until string) ([]string, error) {
+	for _, bound := range []string{since, until} {
+		if bound != "" && v.byName[bound] == nil {
+			return nil, fmt.Errorf("unknown version %s", bound)
+		}
+	}
+
+	var names []string
+	in := since == ""
+	for _, version := range v.list {
+		if version.Name == since {
+			in = true
+		}
+		if in {
+			names = append(names, version.Name)
+		}
+		if version.Name == until {
+			break
+		}
+	}
+
+	return names, nil
+}
+
+// Negotiate parses an Accept-Version header value such as "v2" or "2"
+func (v *Versions) Negotiate(header string) (string, bool) {
+	header = strings.ToLower(strings.TrimSpace(header))
+	if _, err := strconv.Atoi(header); err == nil {
+		header = "v" + header
+	}
+
+	_, ok := v.byName[header]
+	return header, ok
+}
+
+// Middleware marks the responses of version name and counts its requests:
+// API-Version is always set, deprecated versions also get Deprecation, Sunset and Link
+func (v *Versions) Middleware(name string) gin.HandlerFunc {
+	version := v.byName[name]
+
+	return func(c *gin.Context) {
+		c.Header("API-Version", version.Name)
+
+		deprecated := version.IsDeprecated()
+		if deprecated {
+			c.Header("Deprecation", fmt.Sprintf("@%d", version.deprecatedAt.Unix()))
+			if version.Link != "" {
+				c.Header("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", version.Link))
+			}
+		}
+		if !version.sunsetAt.IsZero() {
+			c.Header("Sunset", version.sunsetAt.UTC().Format(http.TimeFormat))
+		}
+
+		requestsTotal.Inc(version.Name, strconv.FormatBool(deprecated))
+
+		c.Next()
+	}
+}
+
+// Handler serves requests without a version prefix with the version from the Accept-Version
+// header, or the default version, by adding the prefix before engine routes them. Only paths
+// whose first segment engine serves under a version prefix are rewritten, /company but not
+// /swagger, so Handler is built once every route is registered.
+func (v *Versions) Handler(engine *gin.Engine) http.Handler {
+	segments := map[string]bool{}
+	for _, route := range engine.Routes() {
+		parts := strings.SplitN(strings.TrimPrefix(route.Path, "/"), "/", 3)
+		if len(parts) > 1 && v.byName[parts[0]] != nil {
+			segments[parts[1]] = true
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		first := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+		if v.Default == "" || !segments[first] {
+			engine.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", HeaderAcceptVersion)
+
+		name := v.Default
+		if header := r.Header.Get(HeaderAcceptVersion); header != "" {
+			var ok bool
+			if name, ok = v.Negotiate(header); !ok {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(models.ResponseModel{
+					Code:    http.StatusBadRequest,
+					Message: "unsupported version",
+					Error:   fmt.Sprintf("%s must be one of %s", HeaderAcceptVersion, strings.Join(v.Names(), ", ")),
+				})
+				return
+			}
+		}
+
+		r = r.WithContext(r.Context())
+		u := *r.URL
+		u.Path = "/" + name + u.Path
+		if u.RawPath != "" {
+			u.RawPath = "/" + name + u.RawPath
+		}
+		r.URL = &u
+		engine.ServeHTTP(w, r)
+	})
+}
+
+// NotFound is the NoRoute handler, answering 404 in the response envelope
+func NotFound(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusNotFound, models.ResponseModel{
+		Code:    http.StatusNotFound,
+		Message: "not found",
+		Error:   c.Request.URL.Path,
+	})
+}
@@ -0,0 +1,76 @@
+package versioning
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandler(t *testing.T) {
+	versions, err := New([]*Version{{Name: "v1"}, {Name: "v2"}}, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	var runs int
+	engine.Use(func(c *gin.Context) {
+		runs++
+		c.Next()
+	})
+	for _, name := range versions.Names() {
+		name := name
+		engine.GET("/"+name+"/company", func(c *gin.Context) {
+			c.String(http.StatusOK, name)
+		})
+	}
+	engine.GET("/swagger/*any", func(c *gin.Context) {
+		c.String(http.StatusOK, "swagger")
+	})
+	engine.NoRoute(NotFound)
+	handler := versions.Handler(engine)
+
+	tests := []struct {
+		path, acceptVersion string
+		code                int
+		body                string
+		// runs is how often the global middleware ran
+		runs int
+	}{
+		{path: "/company", code: http.StatusOK, body: "v1", runs: 1},
+		{path: "/company", acceptVersion: "v2", code: http.StatusOK, body: "v2", runs: 1},
+		{path: "/company", acceptVersion: "2", code: http.StatusOK, body: "v2", runs: 1},
+		{path: "/company", acceptVersion: "v9", code: http.StatusBadRequest},
+		{path: "/v2/company", acceptVersion: "v1", code: http.StatusOK, body: "v2", runs: 1},
+		{path: "/swagger/index.html", code: http.StatusOK, body: "swagger", runs: 1},
+		{path: "/position", code: http.StatusNotFound, runs: 1},
+		{path: "/v9/company", code: http.StatusNotFound, runs: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.acceptVersion, func(t *testing.T) {
+			runs = 0
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptVersion != "" {
+				req.Header.Set(HeaderAcceptVersion, tt.acceptVersion)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("code %d, want %d", w.Code, tt.code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("body %q, want %q", w.Body.String(), tt.body)
+			}
+			if runs != tt.runs {
+				t.Errorf("middleware ran %d times, want %d", runs, tt.runs)
+			}
+			if req.URL.Path != tt.path {
+				t.Errorf("request path changed to %s", req.URL.Path)
+			}
+		})
+	}
+}
@@ -3,11 +3,13 @@ package main
 import (
 	// "fmt"
 
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/xfirdavs/api_gateway/api"
+	"github.com/xfirdavs/api_gateway/api/routes"
 	"github.com/xfirdavs/api_gateway/config"
 	"github.com/xfirdavs/api_gateway/pkg/logger"
 	"github.com/xfirdavs/api_gateway/services"
@@ -17,16 +19,42 @@ func main() {
 	cfg := config.Load()
 	log := logger.New(cfg.LogLevel, "api_gateway")
 
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("invalid config", logger.Error(err))
+	}
+
 	gprcClients, _ := services.NewGrpcClients(&cfg)
 
-	server := api.New(&api.RouterOptions{
+	routeAPI, err := routes.Load(cfg.RoutesFile)
+	if err != nil {
+		log.Fatal("error while loading routes", logger.Error(err))
+	}
+
+	var resolver *services.Resolver
+	if cfg.RPCProxyEnabled {
+		resolver, err = services.NewResolver(gprcClients, cfg.RPCDescriptorSet)
+		if err != nil {
+			log.Fatal("error while creating rpc resolver", logger.Error(err))
+		}
+	}
+
+	server, err := api.New(&api.RouterOptions{
 		Log:      log,
 		Cfg:      cfg,
 		Services: gprcClients,
+		API:      routeAPI,
+		Resolver: resolver,
 	})
+	if err != nil {
+		log.Fatal("error while creating router", logger.Error(err))
+	}
 
 	quit := make(chan os.Signal, 1)
-	go server.Run(cfg.HttpPort)
+	go func() {
+		if err := http.ListenAndServe(cfg.HttpPort, server); err != nil {
+			log.Fatal("error while serving", logger.Error(err))
+		}
+	}()
 
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -1,11 +1,39 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cast"
 )
 
+const (
+	// DevelopEnvironment ...
+	DevelopEnvironment = "develop"
+	// StagingEnvironment ...
+	StagingEnvironment = "staging"
+	// ProductionEnvironment ...
+	ProductionEnvironment = "production"
+)
+
+const (
+	// JSONNamesProto writes the proto field names in responses, e.g. company_id
+	JSONNamesProto = "proto"
+	// JSONNamesJSON writes the lowerCamelCase JSON names in responses, e.g. companyId
+	JSONNamesJSON = "json"
+)
+
+const (
+	// SourceEnv means the value was read from the environment
+	SourceEnv = "env"
+	// SourceDefault means the built-in default was used
+	SourceDefault = "default"
+
+	maskedValue = "******"
+)
+
 // Config ...
 type Config struct {
 	Environment string // develop, staging, production
@@ -18,6 +46,74 @@ type Config struct {
 
 	LogLevel string
 	HttpPort string
+
+	AdminToken string
+
+	RoutesFile string
+
+	RPCProxyEnabled   bool
+	RPCDescriptorSet  string
+	RPCAllowedMethods []string
+
+	// CursorSecret signs the page cursors, required outside develop: without it every process
+	// signs with a random key and cursors break on restarts and across replicas
+	CursorSecret string
+
+	// MaxBodySize limits request bodies in bytes and JSONDiscardUnknown drops unknown
+	// body fields instead of rejecting them, for the routes that do not set their own
+	MaxBodySize        int64
+	JSONDiscardUnknown bool
+
+	// JSONFieldNames is the naming of response fields, JSONEmitUnpopulated writes fields with
+	// zero values and JSONInt64AsString writes 64-bit integers as strings
+	JSONFieldNames      string
+	JSONEmitUnpopulated bool
+	JSONInt64AsString   bool
+
+	// CompressionEncodings are the response content codings offered, most preferred first, none
+	// disables response compression. Responses of CompressionContentTypes from CompressionMinSize
+	// bytes on are compressed at the level of their coding.
+	CompressionEncodings    []string
+	CompressionMinSize      int
+	CompressionContentTypes []string
+	CompressionGzipLevel    int
+	CompressionBrotliLevel  int
+	CompressionZstdLevel    int
+
+	// ReferenceCacheTTL is how long an existing referenced resource is remembered, 0 disables the
+	// cache. ReferenceCacheMaxEntries bounds the remembered resources.
+	ReferenceCacheTTL        time.Duration
+	ReferenceCacheMaxEntries int
+
+	// CacheTTL is how long the responses of the GetAll and GetById methods of CacheServices
+	// are cached at most, 0 disables the cache. CacheMaxEntries bounds the cached responses.
+	CacheTTL        time.Duration
+	CacheMaxEntries int
+	CacheServices   []string
+
+	// CoalesceMethods lets concurrent identical calls of the matching read methods share one
+	// backend call: service/method, service/* or *
+	CoalesceMethods []string
+
+	// IdempotencyTTL is how long the response of a POST or PATCH sent with an Idempotency-Key
+	// is replayed to its repeats, 0 disables idempotency keys. IdempotencyMaxBytes bounds the
+	// memory of the stored responses.
+	IdempotencyTTL      time.Duration
+	IdempotencyMaxBytes int64
+
+	// BatchMaxItems bounds the operations of a batch request, BatchConcurrency the ones run at a time
+	BatchMaxItems    int
+	BatchConcurrency int
+
+	values []Value
+}
+
+// Value describes a single effective config value and where it came from
+type Value struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+	Secret bool        `json:"secret"`
 }
 
 // Load loads environment vars and inflates Config
@@ -27,26 +123,154 @@ func Load() Config {
 	// }
 
 	config := Config{}
+	l := &loader{}
+
+	config.Environment = cast.ToString(l.getOrReturnDefault("ENVIRONMENT", DevelopEnvironment))
+
+	config.LogLevel = cast.ToString(l.getOrReturnDefault("LOG_LEVEL", "debug"))
+	config.HttpPort = cast.ToString(l.getOrReturnDefault("HTTP_PORT", ":8080"))
+
+	config.PositionServiceHost = cast.ToString(l.getOrReturnDefault("POSITION_SERVICE_HOST", "localhost"))
+	config.PositionServicePort = cast.ToInt(l.getOrReturnDefault("POSITION_SERVICE_PORT", 9102))
+
+	config.CompanyServiceHost = cast.ToString(l.getOrReturnDefault("COMPANY_SERVICE_HOST", "localhost"))
+	config.CompanyServicePort = cast.ToInt(l.getOrReturnDefault("COMPANY_SERVICE_PORT", 9105))
+
+	config.AdminToken = cast.ToString(l.getSecret("ADMIN_TOKEN", ""))
+
+	config.RoutesFile = cast.ToString(l.getOrReturnDefault("ROUTES_FILE", ""))
+
+	config.RPCProxyEnabled = cast.ToBool(l.getOrReturnDefault("RPC_PROXY_ENABLED", false))
+	config.RPCDescriptorSet = cast.ToString(l.getOrReturnDefault("RPC_DESCRIPTOR_SET", ""))
+	config.RPCAllowedMethods = splitList(cast.ToString(l.getOrReturnDefault("RPC_ALLOWED_METHODS", "")))
+
+	config.CursorSecret = cast.ToString(l.getSecret("CURSOR_SECRET", ""))
+
+	config.MaxBodySize = cast.ToInt64(l.getOrReturnDefault("MAX_BODY_SIZE", 1<<20))
+	config.JSONDiscardUnknown = cast.ToBool(l.getOrReturnDefault("JSON_DISCARD_UNKNOWN", false))
+
+	config.JSONFieldNames = cast.ToString(l.getOrReturnDefault("JSON_FIELD_NAMES", JSONNamesProto))
+	config.JSONEmitUnpopulated = cast.ToBool(l.getOrReturnDefault("JSON_EMIT_UNPOPULATED", true))
+	config.JSONInt64AsString = cast.ToBool(l.getOrReturnDefault("JSON_INT64_AS_STRING", false))
+
+	config.CompressionEncodings = splitList(cast.ToString(l.getOrReturnDefault("COMPRESSION_ENCODINGS", "br,zstd,gzip")))
+	config.CompressionMinSize = cast.ToInt(l.getOrReturnDefault("COMPRESSION_MIN_SIZE", 1024))
+	config.CompressionContentTypes = splitList(cast.ToString(l.getOrReturnDefault("COMPRESSION_CONTENT_TYPES",
+		"application/json,application/x-protobuf,application/msgpack,application/yaml,text/*,application/javascript")))
+	config.CompressionGzipLevel = cast.ToInt(l.getOrReturnDefault("COMPRESSION_GZIP_LEVEL", 6))
+	config.CompressionBrotliLevel = cast.ToInt(l.getOrReturnDefault("COMPRESSION_BROTLI_LEVEL", 4))
+	config.CompressionZstdLevel = cast.ToInt(l.getOrReturnDefault("COMPRESSION_ZSTD_LEVEL", 3))
 
-	config.Environment = cast.ToString(getOrReturnDefault("ENVIRONMENT", "develop"))
+	config.ReferenceCacheTTL = cast.ToDuration(l.getOrReturnDefault("REFERENCE_CACHE_TTL", "30s"))
+	config.ReferenceCacheMaxEntries = cast.ToInt(l.getOrReturnDefault("REFERENCE_CACHE_MAX_ENTRIES", 10000))
 
-	config.LogLevel = cast.ToString(getOrReturnDefault("LOG_LEVEL", "debug"))
-	config.HttpPort = cast.ToString(getOrReturnDefault("HTTP_PORT", ":8080"))
+	config.CacheTTL = cast.ToDuration(l.getOrReturnDefault("CACHE_TTL", "60s"))
+	config.CacheMaxEntries = cast.ToInt(l.getOrReturnDefault("CACHE_MAX_ENTRIES", 10000))
+	config.CacheServices = splitList(cast.ToString(l.getOrReturnDefault("CACHE_SERVICES",
+		"position_service.ProfessionService,position_service.AttributeService")))
 
-	config.PositionServiceHost = cast.ToString(getOrReturnDefault("POSITION_SERVICE_HOST", "localhost"))
-	config.PositionServicePort = cast.ToInt(getOrReturnDefault("POSITION_SERVICE_PORT", 9102))
+	config.CoalesceMethods = splitList(cast.ToString(l.getOrReturnDefault("COALESCE_METHODS", "company_service.CompanyService/GetById")))
 
-	config.CompanyServiceHost = cast.ToString(getOrReturnDefault("COMPANY_SERVICE_HOST", "localhost"))
-	config.CompanyServicePort = cast.ToInt(getOrReturnDefault("COMPANY_SERVICE_PORT", 9105))
+	config.IdempotencyTTL = cast.ToDuration(l.getOrReturnDefault("IDEMPOTENCY_TTL", "24h"))
+	config.IdempotencyMaxBytes = cast.ToInt64(l.getOrReturnDefault("IDEMPOTENCY_MAX_BYTES", 64<<20))
+
+	config.BatchMaxItems = cast.ToInt(l.getOrReturnDefault("BATCH_MAX_ITEMS", 1000))
+	config.BatchConcurrency = cast.ToInt(l.getOrReturnDefault("BATCH_CONCURRENCY", 10))
+
+	config.values = l.values
 
 	return config
 }
 
-func getOrReturnDefault(key string, defaultValue interface{}) interface{} {
-	_, exists := os.LookupEnv(key)
-	if exists {
-		return os.Getenv(key)
+// Validate checks values that can not be fixed by falling back to a default
+func (c *Config) Validate() error {
+	switch c.Environment {
+	case DevelopEnvironment, StagingEnvironment, ProductionEnvironment:
+	default:
+		return fmt.Errorf("invalid ENVIRONMENT %q, must be one of %s, %s, %s",
+			c.Environment, DevelopEnvironment, StagingEnvironment, ProductionEnvironment)
+	}
+
+	if c.CursorSecret == "" && c.Environment != DevelopEnvironment {
+		return fmt.Errorf("CURSOR_SECRET is required in the %s environment", c.Environment)
+	}
+
+	switch c.JSONFieldNames {
+	case JSONNamesProto, JSONNamesJSON:
+	default:
+		return fmt.Errorf("invalid JSON_FIELD_NAMES %q, must be %s or %s", c.JSONFieldNames, JSONNamesProto, JSONNamesJSON)
+	}
+
+	if c.ReferenceCacheTTL > 0 && c.ReferenceCacheMaxEntries <= 0 {
+		return fmt.Errorf("invalid REFERENCE_CACHE_MAX_ENTRIES %d, must be positive when REFERENCE_CACHE_TTL is set", c.ReferenceCacheMaxEntries)
+	}
+	if c.CacheTTL > 0 && c.CacheMaxEntries <= 0 {
+		return fmt.Errorf("invalid CACHE_MAX_ENTRIES %d, must be positive when CACHE_TTL is set", c.CacheMaxEntries)
 	}
 
-	return defaultValue
+	if c.IdempotencyTTL > 0 && c.IdempotencyMaxBytes <= 0 {
+		return fmt.Errorf("invalid IDEMPOTENCY_MAX_BYTES %d, must be positive when IDEMPOTENCY_TTL is set", c.IdempotencyMaxBytes)
+	}
+
+	if c.BatchMaxItems <= 0 {
+		return fmt.Errorf("invalid BATCH_MAX_ITEMS %d, must be positive", c.BatchMaxItems)
+	}
+	if c.BatchConcurrency <= 0 {
+		return fmt.Errorf("invalid BATCH_CONCURRENCY %d, must be positive", c.BatchConcurrency)
+	}
+
+	return nil
+}
+
+// Effective returns every loaded value with its source, secrets are masked
+func (c *Config) Effective() []Value {
+	values := make([]Value, 0, len(c.values))
+	for _, v := range c.values {
+		if v.Secret && cast.ToString(v.Value) != "" {
+			v.Value = maskedValue
+		}
+		values = append(values, v)
+	}
+
+	return values
+}
+
+// splitList splits a comma separated value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+type loader struct {
+	values []Value
+}
+
+func (l *loader) getOrReturnDefault(key string, defaultValue interface{}) interface{} {
+	return l.load(key, defaultValue, false)
+}
+
+func (l *loader) getSecret(key string, defaultValue interface{}) interface{} {
+	return l.load(key, defaultValue, true)
+}
+
+func (l *loader) load(key string, defaultValue interface{}, secret bool) interface{} {
+	value, source := defaultValue, SourceDefault
+	if v, exists := os.LookupEnv(key); exists {
+		value, source = v, SourceEnv
+	}
+
+	l.values = append(l.values, Value{
+		Key:    key,
+		Value:  value,
+		Source: source,
+		Secret: secret,
+	})
+
+	return value
 }
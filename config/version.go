@@ -0,0 +1,9 @@
+package config
+
+// Build information, overridden at build time with
+// -ldflags "-X github.com/xfirdavs/api_gateway/config.Version=..."
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+	BuildTime = ""
+)
@@ -0,0 +1,200 @@
+package attrtype
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xfirdavs/api_gateway/pkg/helper"
+	"github.com/xfirdavs/api_gateway/pkg/util"
+)
+
+// ErrUnknownType is returned by Parse for a type that is not registered
+var ErrUnknownType = errors.New("unknown attribute type")
+
+// Validator checks a value of an attribute type
+type Validator func(value string) error
+
+// Kind builds the validator of a type from the argument after the colon,
+// e.g. "a,b,c" in enum:a,b,c, empty for types without argument
+type Kind func(arg string) (Validator, error)
+
+var (
+	mu    sync.RWMutex
+	kinds = map[string]Kind{}
+)
+
+// the built-in types; date is DD-MM-YYYY
+func init() {
+	Register("string", plain(func(string) error { return nil }))
+	Register("number", plain(validateNumber))
+	Register("integer", plain(validateInteger))
+	Register("boolean", plain(validateBoolean))
+	Register("date", plain(helper.ValidateDate))
+	Register("phone", phoneKind)
+	Register("email", plain(validateEmail))
+	Register("url", plain(validateURL))
+	Register("enum", enumKind)
+	Register("regex", regexKind)
+}
+
+// Register adds the kind name, replacing a kind registered before under the same name
+func Register(name string, kind Kind) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	kinds[name] = kind
+}
+
+// Names returns the registered kinds, sorted
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Parse returns the validator of an attribute type such as integer, enum:junior,middle,senior
+// or regex:^[A-Z]{2}$. Types are parsed on every call: they come from clients, so a cache
+// of their validators would grow without bound.
+func Parse(typ string) (Validator, error) {
+	name, arg := strings.TrimSpace(typ), ""
+	if i := strings.Index(name, ":"); i >= 0 {
+		name, arg = name[:i], name[i+1:]
+	}
+
+	mu.RLock()
+	kind, ok := kinds[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q, known types: %s", ErrUnknownType, typ, strings.Join(Names(), ", "))
+	}
+
+	v, err := kind(arg)
+	if err != nil {
+		return nil, fmt.Errorf("attribute type %q: %w", typ, err)
+	}
+
+	return v, nil
+}
+
+// plain is the kind of a type that takes no argument
+func plain(v Validator) Kind {
+	return func(arg string) (Validator, error) {
+		if arg != "" {
+			return nil, errors.New("takes no argument")
+		}
+		return v, nil
+	}
+}
+
+func enumKind(arg string) (Validator, error) {
+	var values []string
+	for _, value := range strings.Split(arg, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return nil, errors.New("enum needs values, e.g. enum:junior,middle,senior")
+	}
+
+	return func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(values, ", "))
+	}, nil
+}
+
+func regexKind(arg string) (Validator, error) {
+	if arg == "" {
+		return nil, errors.New("regex needs a pattern, e.g. regex:^[A-Z]{2}$")
+	}
+	r, err := regexp.Compile(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(value string) error {
+		if !r.MatchString(value) {
+			return fmt.Errorf("must match %s", arg)
+		}
+		return nil
+	}, nil
+}
+
+func validateNumber(value string) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return errors.New("must be a number")
+	}
+	return nil
+}
+
+func validateInteger(value string) error {
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return errors.New("must be an integer")
+	}
+	return nil
+}
+
+func validateBoolean(value string) error {
+	if value != "true" && value != "false" {
+		return errors.New("must be true or false")
+	}
+	return nil
+}
+
+// phoneKind is the kind of international phone numbers, +<country code><number> with at most
+// 15 digits. The argument restricts the country code, e.g. phone:998 for Uzbekistan.
+func phoneKind(arg string) (Validator, error) {
+	code := strings.TrimPrefix(strings.TrimSpace(arg), "+")
+	if arg != "" && !countryCodePattern.MatchString(code) {
+		return nil, errors.New("phone takes a country calling code, e.g. phone:998")
+	}
+
+	return func(value string) error {
+		if !phonePattern.MatchString(value) || !strings.HasPrefix(value, "+"+code) {
+			if code == "" {
+				return errors.New("must be an international phone number, e.g. +998901234567")
+			}
+			return fmt.Errorf("must be a phone number +%s...", code)
+		}
+		return nil
+	}, nil
+}
+
+var (
+	phonePattern       = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+	countryCodePattern = regexp.MustCompile(`^[1-9][0-9]{0,2}$`)
+)
+
+func validateEmail(value string) error {
+	if !util.IsValidEmail(value) {
+		return errors.New("must be an email address")
+	}
+	return nil
+}
+
+func validateURL(value string) error {
+	u, err := url.ParseRequestURI(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an http or https URL")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Control holds the Cache-Control directives the gateway acts on. MaxAge and
+// SMaxAge are -1 when the directive is absent.
+type Control struct {
+	NoStore bool
+	NoCache bool
+	Private bool
+	MaxAge  time.Duration
+	SMaxAge time.Duration
+}
+
+// ParseControl parses a Cache-Control header, unknown directives are ignored
+// and a max-age that is not a number of seconds counts as 0
+func ParseControl(header string) Control {
+	c := Control{MaxAge: -1, SMaxAge: -1}
+
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			c.NoStore = true
+		case "no-cache":
+			c.NoCache = true
+		case "private":
+			c.Private = true
+		case "max-age":
+			c.MaxAge = seconds(value)
+		case "s-maxage":
+			c.SMaxAge = seconds(value)
+		}
+	}
+
+	return c
+}
+
+// Fresh reports whether an entry of the given age may answer a request with these directives
+func (c Control) Fresh(age time.Duration) bool {
+	if c.NoStore || c.NoCache {
+		return false
+	}
+
+	return c.MaxAge < 0 || age <= c.MaxAge
+}
+
+// TTL returns how long a shared cache may keep a response with these directives, at most
+// max, and false when the response must not be stored
+func (c Control) TTL(max time.Duration) (time.Duration, bool) {
+	if c.NoStore || c.NoCache || c.Private {
+		return 0, false
+	}
+
+	ttl := c.MaxAge
+	if c.SMaxAge >= 0 {
+		ttl = c.SMaxAge
+	}
+	if ttl < 0 || ttl > max {
+		ttl = max
+	}
+
+	return ttl, ttl > 0
+}
+
+func seconds(value string) time.Duration {
+	n, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a cached response and the time it was stored
+type Entry struct {
+	Value  []byte
+	Stored time.Time
+}
+
+// Backend stores cached responses, in memory with LRU or in a cache shared by
+// the gateway instances. A backend that fails to answer reports a miss.
+type Backend interface {
+	Get(ctx context.Context, key string) (Entry, bool)
+	Set(ctx context.Context, key string, e Entry, ttl time.Duration)
+	// DeletePrefix removes every key starting with prefix
+	DeletePrefix(ctx context.Context, prefix string)
+}
+
+// LRU is an in-memory Backend keeping at most max entries, the least recently
+// used entry is dropped to make room for a new one
+type LRU struct {
+	max int
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+type lruItem struct {
+	key     string
+	entry   Entry
+	expires time.Time
+}
+
+// NewLRU creates an LRU of max entries
+func NewLRU(max int) *LRU {
+	return &LRU{
+		max:   max,
+		order: list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+// Get returns the entry of key unless it is missing or expired
+func (c *LRU) Get(_ context.Context, key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return Entry{}, false
+	}
+	item := el.Value.(*lruItem)
+	if time.Now().After(item.expires) {
+		c.remove(el)
+		return Entry{}, false
+	}
+	c.order.MoveToFront(el)
+
+	return item.entry, true
+}
+
+// Set stores e under key for ttl
+func (c *LRU) Set(_ context.Context, key string, e Entry, ttl time.Duration) {
+	if ttl <= 0 || c.max <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		el.Value = &lruItem{key: key, entry: e, expires: expires}
+		c.order.MoveToFront(el)
+		return
+	}
+
+	for c.order.Len() >= c.max {
+		c.remove(c.order.Back())
+	}
+	c.items[key] = c.order.PushFront(&lruItem{key: key, entry: e, expires: expires})
+}
+
+// Delete removes key
+func (c *LRU) Delete(_ context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// DeletePrefix removes every key starting with prefix
+func (c *LRU) DeletePrefix(_ context.Context, prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(el)
+		}
+	}
+}
+
+// Len returns the number of entries, expired ones included
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*lruItem).key)
+}
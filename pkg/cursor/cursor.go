@@ -0,0 +1,78 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInvalid is returned for a cursor that was not issued by the Signer or was modified
+var ErrInvalid = errors.New("invalid cursor")
+
+// Cursor is the position of a page in a list, bound to the query it was issued for
+type Cursor struct {
+	Offset int    `json:"o"`
+	Limit  int    `json:"l"`
+	Query  string `json:"q,omitempty"`
+}
+
+// Signer encodes cursors as opaque strings signed with HMAC-SHA256
+type Signer struct {
+	key []byte
+}
+
+// NewSigner creates a Signer, with an empty secret a random key is used and cursors stop
+// being valid when the process restarts and on the other instances of the gateway
+func NewSigner(secret string) *Signer {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(err)
+		}
+	}
+
+	return &Signer{key: key}
+}
+
+// Encode returns the opaque form of c
+func (s *Signer) Encode(c Cursor) string {
+	payload, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// Decode verifies and parses a cursor returned by Encode
+func (s *Signer) Decode(value string) (Cursor, error) {
+	var c Cursor
+
+	parts := strings.Split(value, ".")
+	if len(parts) != 2 {
+		return c, ErrInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return c, ErrInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, s.sign(payload)) {
+		return c, ErrInvalid
+	}
+
+	if err := json.Unmarshal(payload, &c); err != nil || c.Offset < 0 || c.Limit < 1 {
+		return c, ErrInvalid
+	}
+
+	return c, nil
+}
+
+func (s *Signer) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
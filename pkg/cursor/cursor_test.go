@@ -0,0 +1,78 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// signed returns payload signed by s, for cursors Encode would not issue
+func signed(s *Signer, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign([]byte(payload)))
+}
+
+func TestDecode(t *testing.T) {
+	signer := NewSigner("secret")
+	c := Cursor{Offset: 20, Limit: 10, Query: "search=acme"}
+	valid := signer.Encode(c)
+	payload, signature, _ := strings.Cut(valid, ".")
+
+	// tampered is payload of another offset with the signature of c
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"o":1000,"l":10,"q":"search=acme"}`)) + "." + signature
+
+	tests := []struct {
+		name   string
+		signer *Signer
+		value  string
+		// invalid expects ErrInvalid, otherwise c is decoded
+		invalid bool
+	}{
+		{name: "valid", signer: signer, value: valid},
+		{name: "same secret", signer: NewSigner("secret"), value: valid},
+		{name: "other secret", signer: NewSigner("other"), value: valid, invalid: true},
+		{name: "random key", signer: NewSigner(""), value: valid, invalid: true},
+		{name: "tampered payload", signer: signer, value: tampered, invalid: true},
+		{name: "tampered signature", signer: signer, value: payload + "." + signature[:len(signature)-2] + "AA", invalid: true},
+		{name: "no signature", signer: signer, value: payload, invalid: true},
+		{name: "empty signature", signer: signer, value: payload + ".", invalid: true},
+		{name: "extra part", signer: signer, value: valid + "." + signature, invalid: true},
+		{name: "padded", signer: signer, value: payload + "=." + signature, invalid: true},
+		{name: "not base64", signer: signer, value: "!!." + signature, invalid: true},
+		{name: "empty", signer: signer, value: "", invalid: true},
+		{name: "signed, not JSON", signer: signer, value: signed(signer, "acme"), invalid: true},
+		{name: "signed, negative offset", signer: signer, value: signed(signer, `{"o":-1,"l":10}`), invalid: true},
+		{name: "signed, no limit", signer: signer, value: signed(signer, `{"o":0}`), invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.signer.Decode(tt.value)
+			if tt.invalid {
+				if !errors.Is(err, ErrInvalid) {
+					t.Errorf("Decode(%s) error %v, want ErrInvalid", tt.value, err)
+				}
+				return
+			}
+			if err != nil || got != c {
+				t.Errorf("Decode(%s) = %+v, %v, want %+v", tt.value, got, err, c)
+			}
+		})
+	}
+}
+
+func TestEncodeBindsTheQuery(t *testing.T) {
+	signer := NewSigner("secret")
+	a := signer.Encode(Cursor{Offset: 10, Limit: 10, Query: "search=a"})
+	b := signer.Encode(Cursor{Offset: 10, Limit: 10, Query: "search=b"})
+	if a == b {
+		t.Fatalf("cursors of different queries are equal: %s", a)
+	}
+
+	// the signature of one query does not verify the cursor of another
+	payloadA, _, _ := strings.Cut(a, ".")
+	_, signatureB, _ := strings.Cut(b, ".")
+	if _, err := signer.Decode(payloadA + "." + signatureB); !errors.Is(err, ErrInvalid) {
+		t.Errorf("mixed cursor decoded, error %v", err)
+	}
+}
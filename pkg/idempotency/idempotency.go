@@ -0,0 +1,210 @@
+package idempotency
+
+import (
+	"container/heap"
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Headers of idempotent requests: clients send a unique key with a POST or PATCH, repeats of
+// it are answered with the stored response marked as replayed
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// MaxKeyLength bounds the keys sent by clients
+const MaxKeyLength = 255
+
+// Record is the request of a key and, once Done, its response
+type Record struct {
+	RequestHash string
+
+	Done   bool
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Store keeps the records of the keys for a TTL. Reserve must be atomic so that a store
+// shared by the gateway instances lets one request of a key through.
+type Store interface {
+	// Reserve stores the record of a request in flight under key unless the key is
+	// known, returning the stored record and false then
+	Reserve(ctx context.Context, key string, record Record, ttl time.Duration) (Record, bool, error)
+	// Renew extends the record of a request in flight under key by ttl, the request is still served
+	Renew(ctx context.Context, key string, ttl time.Duration) error
+	// Complete replaces the record of key with the one of the response
+	Complete(ctx context.Context, key string, record Record, ttl time.Duration) error
+	// Release forgets key, the request may be sent again
+	Release(ctx context.Context, key string) error
+}
+
+// Memory is an in-memory Store holding at most maxBytes of records, the least recently used
+// response is dropped to make room for a new record. Records of requests in flight are never
+// dropped for room, only once they expire, so a repeat can not run the request twice.
+// Expired records are dropped in order of expiry.
+type Memory struct {
+	maxBytes int64
+	now      func() time.Time
+
+	mu     sync.Mutex
+	size   int64
+	order  *list.List
+	items  map[string]*list.Element
+	expiry expiryHeap
+}
+
+type memoryItem struct {
+	key     string
+	record  Record
+	expires time.Time
+	size    int64
+	// index is the position of the item in the expiry heap
+	index int
+}
+
+// NewMemory creates an empty in-memory store of at most maxBytes
+func NewMemory(maxBytes int64) *Memory {
+	return &Memory{
+		maxBytes: maxBytes,
+		now:      time.Now,
+		order:    list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+// Reserve stores record under key unless a record that did not expire is there
+func (m *Memory) Reserve(_ context.Context, key string, record Record, ttl time.Duration) (Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expire(m.now())
+	if el, ok := m.items[key]; ok {
+		m.order.MoveToFront(el)
+		return el.Value.(*memoryItem).record, false, nil
+	}
+	m.put(key, record, ttl)
+
+	return record, true, nil
+}
+
+// Renew extends the record of key in flight by ttl
+func (m *Memory) Renew(_ context.Context, key string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expire(m.now())
+	if el, ok := m.items[key]; ok && !el.Value.(*memoryItem).record.Done {
+		item := el.Value.(*memoryItem)
+		item.expires = m.now().Add(ttl)
+		heap.Fix(&m.expiry, item.index)
+		m.order.MoveToFront(el)
+	}
+
+	return nil
+}
+
+// Complete stores the record of the response under key
+func (m *Memory) Complete(_ context.Context, key string, record Record, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expire(m.now())
+	m.put(key, record, ttl)
+
+	return nil
+}
+
+// Release removes key
+func (m *Memory) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+
+	return nil
+}
+
+// put stores record under key and drops the least recently used responses beyond maxBytes,
+// keeping the records in flight and record itself even when it is larger than maxBytes
+func (m *Memory) put(key string, record Record, ttl time.Duration) {
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+
+	item := &memoryItem{key: key, record: record, expires: m.now().Add(ttl), size: recordSize(key, record)}
+	front := m.order.PushFront(item)
+	m.items[key] = front
+	heap.Push(&m.expiry, item)
+	m.size += item.size
+
+	for el := m.order.Back(); m.size > m.maxBytes && el != front; {
+		prev := el.Prev()
+		if el.Value.(*memoryItem).record.Done {
+			m.remove(el)
+		}
+		el = prev
+	}
+}
+
+// expire drops the records expired at now
+func (m *Memory) expire(now time.Time) {
+	for len(m.expiry) > 0 && !now.Before(m.expiry[0].expires) {
+		m.remove(m.items[m.expiry[0].key])
+	}
+}
+
+func (m *Memory) remove(el *list.Element) {
+	item := el.Value.(*memoryItem)
+	m.order.Remove(el)
+	heap.Remove(&m.expiry, item.index)
+	delete(m.items, item.key)
+	m.size -= item.size
+}
+
+// recordOverhead approximates the memory taken by a record besides its key, hash, header and body
+const recordOverhead = 256
+
+func recordSize(key string, record Record) int64 {
+	size := recordOverhead + len(key) + len(record.RequestHash) + len(record.Body)
+	for name, values := range record.Header {
+		size += len(name)
+		for _, v := range values {
+			size += len(v)
+		}
+	}
+
+	return int64(size)
+}
+
+// expiryHeap orders the records by expiry, the first to expire on top
+type expiryHeap []*memoryItem
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].expires.Before(h[j].expires) }
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	item := x.(*memoryItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+
+	return item
+}
@@ -0,0 +1,201 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// step is an operation on the store; reserve checks its result, has whether key is stored
+type step struct {
+	op      string
+	key     string
+	record  Record
+	ttl     time.Duration
+	advance time.Duration
+
+	ok   bool
+	want Record
+}
+
+func inFlight(hash string) Record {
+	return Record{RequestHash: hash}
+}
+
+func done(hash string) Record {
+	return Record{RequestHash: hash, Done: true, Status: 201, Body: bytes.Repeat([]byte("x"), 100)}
+}
+
+func TestMemory(t *testing.T) {
+	const ttl = time.Minute
+
+	tests := []struct {
+		name     string
+		maxBytes int64
+		steps    []step
+	}{
+		{
+			name: "reserve",
+			steps: []step{
+				{op: "reserve", key: "a", record: inFlight("1"), ttl: ttl, ok: true, want: inFlight("1")},
+				{op: "reserve", key: "a", record: inFlight("2"), ttl: ttl, want: inFlight("1")},
+				{op: "reserve", key: "b", record: inFlight("2"), ttl: ttl, ok: true, want: inFlight("2")},
+			},
+		},
+		{
+			name: "complete",
+			steps: []step{
+				{op: "reserve", key: "a", record: inFlight("1"), ttl: ttl, ok: true, want: inFlight("1")},
+				{op: "complete", key: "a", record: done("1"), ttl: ttl},
+				{op: "reserve", key: "a", record: inFlight("1"), ttl: ttl, want: done("1")},
+			},
+		},
+		{
+			name: "release",
+			steps: []step{
+				{op: "reserve", key: "a", record: inFlight("1"), ttl: ttl, ok: true, want: inFlight("1")},
+				{op: "release", key: "a"},
+				{op: "has", key: "a"},
+				{op: "reserve", key: "a", record: inFlight("2"), ttl: ttl, ok: true, want: inFlight("2")},
+				{op: "release", key: "unknown"},
+			},
+		},
+		{
+			name: "expiry",
+			steps: []step{
+				{op: "complete", key: "a", record: done("1"), ttl: ttl},
+				{op: "complete", key: "b", record: done("2"), ttl: 2 * ttl},
+				{op: "advance", advance: ttl - time.Second},
+				{op: "has", key: "a", ok: true, want: done("1")},
+				{op: "advance", advance: time.Second},
+				{op: "reserve", key: "a", record: inFlight("3"), ttl: ttl, ok: true, want: inFlight("3")},
+				{op: "has", key: "b", ok: true, want: done("2")},
+			},
+		},
+		{
+			name: "renew extends a request in flight",
+			steps: []step{
+				{op: "reserve", key: "a", record: inFlight("1"), ttl: ttl, ok: true, want: inFlight("1")},
+				{op: "advance", advance: 50 * time.Second},
+				{op: "renew", key: "a", ttl: ttl},
+				{op: "advance", advance: 50 * time.Second},
+				{op: "reserve", key: "a", record: inFlight("2"), ttl: ttl, want: inFlight("1")},
+			},
+		},
+		{
+			name: "renew does not extend a response",
+			steps: []step{
+				{op: "complete", key: "a", record: done("1"), ttl: ttl},
+				{op: "advance", advance: 50 * time.Second},
+				{op: "renew", key: "a", ttl: time.Hour},
+				{op: "advance", advance: 10 * time.Second},
+				{op: "has", key: "a"},
+			},
+		},
+		{
+			name:     "eviction drops the least recently used response",
+			maxBytes: 800,
+			steps: []step{
+				{op: "complete", key: "a", record: done("1"), ttl: ttl},
+				{op: "complete", key: "b", record: done("2"), ttl: ttl},
+				{op: "reserve", key: "a", record: inFlight("1"), ttl: ttl, want: done("1")},
+				{op: "complete", key: "c", record: done("3"), ttl: ttl},
+				{op: "has", key: "a", ok: true, want: done("1")},
+				{op: "has", key: "b"},
+				{op: "has", key: "c", ok: true, want: done("3")},
+			},
+		},
+		{
+			name:     "eviction keeps requests in flight",
+			maxBytes: 800,
+			steps: []step{
+				{op: "reserve", key: "a", record: inFlight("1"), ttl: ttl, ok: true, want: inFlight("1")},
+				{op: "complete", key: "b", record: done("2"), ttl: ttl},
+				{op: "complete", key: "c", record: done("3"), ttl: ttl},
+				{op: "has", key: "a", ok: true, want: inFlight("1")},
+				{op: "has", key: "b"},
+				{op: "has", key: "c", ok: true, want: done("3")},
+			},
+		},
+		{
+			name:     "renew keeps a request in flight recently used",
+			maxBytes: 800,
+			steps: []step{
+				{op: "complete", key: "a", record: done("1"), ttl: ttl},
+				{op: "reserve", key: "b", record: inFlight("2"), ttl: ttl, ok: true, want: inFlight("2")},
+				{op: "renew", key: "b", ttl: ttl},
+				{op: "complete", key: "c", record: done("3"), ttl: ttl},
+				{op: "complete", key: "d", record: done("4"), ttl: ttl},
+				{op: "has", key: "a"},
+				{op: "has", key: "b", ok: true, want: inFlight("2")},
+			},
+		},
+		{
+			name:     "a record larger than the store is kept alone",
+			maxBytes: 100,
+			steps: []step{
+				{op: "complete", key: "a", record: done("1"), ttl: ttl},
+				{op: "complete", key: "b", record: done("2"), ttl: ttl},
+				{op: "has", key: "a"},
+				{op: "has", key: "b", ok: true, want: done("2")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxBytes := tt.maxBytes
+			if maxBytes == 0 {
+				maxBytes = 1 << 20
+			}
+			m := NewMemory(maxBytes)
+			now := time.Unix(0, 0)
+			m.now = func() time.Time { return now }
+
+			ctx := context.Background()
+			for i, s := range tt.steps {
+				var err error
+				switch s.op {
+				case "reserve":
+					var got Record
+					var ok bool
+					got, ok, err = m.Reserve(ctx, s.key, s.record, s.ttl)
+					if ok != s.ok || !reflect.DeepEqual(got, s.want) {
+						t.Errorf("step %d: Reserve %s = %+v, %v, want %+v, %v", i, s.key, got, ok, s.want, s.ok)
+					}
+				case "renew":
+					err = m.Renew(ctx, s.key, s.ttl)
+				case "complete":
+					err = m.Complete(ctx, s.key, s.record, s.ttl)
+				case "release":
+					err = m.Release(ctx, s.key)
+				case "advance":
+					now = now.Add(s.advance)
+				case "has":
+					m.expire(now)
+					el, ok := m.items[s.key]
+					switch {
+					case ok != s.ok:
+						t.Errorf("step %d: %s stored %v, want %v", i, s.key, ok, s.ok)
+					case ok && !reflect.DeepEqual(el.Value.(*memoryItem).record, s.want):
+						t.Errorf("step %d: %s = %+v, want %+v", i, s.key, el.Value.(*memoryItem).record, s.want)
+					}
+				}
+				if err != nil {
+					t.Fatalf("step %d: %s: %v", i, s.op, err)
+				}
+			}
+
+			var size int64
+			for el := m.order.Front(); el != nil; el = el.Next() {
+				size += el.Value.(*memoryItem).size
+			}
+			if size != m.size || len(m.items) != m.order.Len() || len(m.expiry) != m.order.Len() {
+				t.Errorf("inconsistent store: size %d, counted %d, %d items, %d in order, %d in expiry",
+					m.size, size, len(m.items), m.order.Len(), len(m.expiry))
+			}
+		})
+	}
+}
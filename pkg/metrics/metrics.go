@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Counter is a monotonically increasing value partitioned by label values
+type Counter struct {
+	vec *prometheus.CounterVec
+}
+
+// NewCounter creates a counter and registers it with the default Prometheus registry
+func NewCounter(name, help string, labels ...string) *Counter {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	prometheus.MustRegister(vec)
+
+	return &Counter{vec: vec}
+}
+
+// Inc adds one to the counter of the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Inc()
+}
+
+// Add adds n to the counter of the given label values
+func (c *Counter) Add(n uint64, labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Add(float64(n))
+}
+
+// Handler serves the metrics of the default Prometheus registry, the registered counters
+// with the Go runtime and process metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
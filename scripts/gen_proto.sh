@@ -1,5 +1,6 @@
 #!/bin/bash
 CURRENT_DIR=$1
+# google/api/annotations.proto and google/api/http.proto (googleapis) are expected in /usr/local/include
 rm -rf ${CURRENT_DIR}/genproto
 for x in $(find ${CURRENT_DIR}/protos/* -type d); do
   protoc -I=${x} -I=${CURRENT_DIR}/protos -I /usr/local/include --go_out=${CURRENT_DIR} \
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xfirdavs/api_gateway/pkg/cache"
+	"github.com/xfirdavs/api_gateway/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// AllServices in CacheOptions.Services caches the read methods of every service
+const AllServices = "*"
+
+// Results of a cache lookup
+const (
+	CacheHit    = "HIT"
+	CacheMiss   = "MISS"
+	cacheBypass = "BYPASS"
+)
+
+var (
+	// readPrefixes and writePrefixes name the cached methods and the methods invalidating their service
+	readPrefixes  = []string{"GetAll", "GetById"}
+	writePrefixes = []string{"Create", "Update", "Delete"}
+
+	cacheRequestsTotal = metrics.NewCounter(
+		"grpc_cache_requests_total",
+		"Calls of cached gRPC methods per result: HIT, MISS or BYPASS",
+		"method", "result",
+	)
+	cacheInvalidationsTotal = metrics.NewCounter(
+		"grpc_cache_invalidations_total",
+		"Cached responses of a service dropped by a create, update or delete",
+		"service",
+	)
+)
+
+// CacheOptions configures the response cache of the backend read methods
+type CacheOptions struct {
+	// TTL is how long a response is kept at most, 0 disables the cache
+	TTL time.Duration
+	// Services are the full service names cached, e.g. position_service.ProfessionService, or AllServices
+	Services []string
+	// Backend stores the responses, an in-memory LRU of MaxEntries by default
+	Backend    cache.Backend
+	MaxEntries int
+}
+
+// ResponseCache caches the responses of the GetAll and GetById methods of backend
+// services, keyed by method and request. A Create, Update or Delete sent through the
+// gateway drops the cached responses of its service. Backends shorten the TTL or forbid
+// storing a response with a cache-control response header: max-age, s-maxage, no-store,
+// no-cache or private.
+type ResponseCache struct {
+	ttl      time.Duration
+	services map[string]bool
+	backend  cache.Backend
+
+	// generations counts the invalidations of every service, a response fetched
+	// while its service was invalidated is not stored
+	mu          sync.Mutex
+	generations map[string]uint64
+}
+
+// NewResponseCache creates the cache, nil when opts.TTL is not positive
+func NewResponseCache(opts CacheOptions) *ResponseCache {
+	if opts.TTL <= 0 {
+		return nil
+	}
+
+	rc := &ResponseCache{
+		ttl:         opts.TTL,
+		services:    map[string]bool{},
+		backend:     opts.Backend,
+		generations: map[string]uint64{},
+	}
+	for _, service := range opts.Services {
+		rc.services[service] = true
+	}
+	if rc.backend == nil {
+		rc.backend = cache.NewLRU(opts.MaxEntries)
+	}
+
+	return rc
+}
+
+// Interceptor returns the client interceptor serving and storing the cached responses
+func (rc *ResponseCache) Interceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		service, name := splitMethod(method)
+		if !rc.services[service] && !rc.services[AllServices] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		switch {
+		case hasPrefix(name, readPrefixes):
+			reqMsg, reqOK := req.(proto.Message)
+			replyMsg, replyOK := reply.(proto.Message)
+			if reqOK && replyOK {
+				return rc.read(ctx, service, method, reqMsg, replyMsg, func(extra ...grpc.CallOption) error {
+					return invoker(ctx, method, req, reply, cc, append(opts, extra...)...)
+				})
+			}
+		case hasPrefix(name, writePrefixes):
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			rc.invalidate(ctx, service)
+			return err
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// read answers a read method from the cache when the request directives allow it,
+// otherwise it calls the backend and stores the response unless the backend forbids it
+func (rc *ResponseCache) read(ctx context.Context, service, method string, req, reply proto.Message,
+	invoke func(...grpc.CallOption) error) error {
+	control := cache.ParseControl("")
+	status, _ := ctx.Value(cacheStatusKey{}).(*CacheStatus)
+	if status != nil {
+		control = status.control
+	}
+	if ctx.Value(freshKey{}) != nil {
+		control.NoCache = true
+	}
+	if control.NoStore {
+		cacheRequestsTotal.Inc(method, cacheBypass)
+		return invoke()
+	}
+
+	key, err := requestKey(method, req)
+	if err != nil {
+		cacheRequestsTotal.Inc(method, cacheBypass)
+		return invoke()
+	}
+
+	if e, ok := rc.backend.Get(ctx, key); ok {
+		age := time.Since(e.Stored)
+		if control.Fresh(age) && proto.Unmarshal(e.Value, reply) == nil {
+			cacheRequestsTotal.Inc(method, CacheHit)
+			status.record(CacheHit, age)
+			return nil
+		}
+		proto.Reset(reply)
+	}
+
+	cacheRequestsTotal.Inc(method, CacheMiss)
+	status.record(CacheMiss, 0)
+
+	generation := rc.generation(service)
+	var header metadata.MD
+	if err := invoke(grpc.Header(&header)); err != nil {
+		return err
+	}
+
+	ttl, ok := cache.ParseControl(strings.Join(header.Get("cache-control"), ",")).TTL(rc.ttl)
+	if !ok || rc.generation(service) != generation {
+		return nil
+	}
+	value, err := proto.MarshalOptions{Deterministic: true}.Marshal(reply)
+	if err != nil {
+		return nil
+	}
+	rc.backend.Set(ctx, key, cache.Entry{Value: value, Stored: time.Now()}, ttl)
+
+	return nil
+}
+
+// invalidate drops the cached responses of service
+func (rc *ResponseCache) invalidate(ctx context.Context, service string) {
+	rc.mu.Lock()
+	rc.generations[service]++
+	rc.mu.Unlock()
+
+	rc.backend.DeletePrefix(ctx, "/"+service+"/")
+	cacheInvalidationsTotal.Inc(service)
+}
+
+func (rc *ResponseCache) generation(service string) uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.generations[service]
+}
+
+// requestKey normalizes a request to the deterministic encoding of its message, so requests
+// differing only in field order or in fields set to their zero value share an entry
+func requestKey(method string, req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+
+	return method + "/" + hex.EncodeToString(sum[:]), nil
+}
+
+// splitMethod splits a full method, /package.Service/Method, into the service and the method name
+func splitMethod(method string) (string, string) {
+	method = strings.TrimPrefix(method, "/")
+	if idx := strings.LastIndex(method, "/"); idx >= 0 {
+		return method[:idx], method[idx+1:]
+	}
+
+	return "", method
+}
+
+func hasPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type (
+	cacheStatusKey struct{}
+	freshKey       struct{}
+)
+
+// Fresh returns ctx for backend reads that must not be answered by the cache, e.g. the
+// read of a read-modify-write; their responses are stored still
+func Fresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshKey{}, true)
+}
+
+// CacheStatus carries the Cache-Control directives of a request to the cached backend
+// calls made for it and collects the result of their lookups
+type CacheStatus struct {
+	control cache.Control
+
+	mu     sync.Mutex
+	result string
+	age    time.Duration
+}
+
+// WithCacheControl returns ctx with the Cache-Control header of the request it serves
+func WithCacheControl(ctx context.Context, header string) (context.Context, *CacheStatus) {
+	status := &CacheStatus{control: cache.ParseControl(header)}
+
+	return context.WithValue(ctx, cacheStatusKey{}, status), status
+}
+
+// Result returns CacheHit when every cached call of the request was answered by the cache, with
+// the age of the oldest response used, CacheMiss when one was not, and "" without cached calls
+func (s *CacheStatus) Result() (string, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.result, s.age
+}
+
+func (s *CacheStatus) record(result string, age time.Duration) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.result == CacheMiss {
+		return
+	}
+	s.result = result
+	if age > s.age {
+		s.age = age
+	}
+}
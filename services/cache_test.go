@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xfirdavs/api_gateway/genproto/company_service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// companyStore is an in-memory CompanyService for the cache interceptor, its reads answer
+// the Cache-Control header control and call during once their reply is read
+type companyStore struct {
+	names   map[string]string
+	calls   int
+	control string
+	during  func()
+}
+
+func (s *companyStore) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+	s.calls++
+	switch req := req.(type) {
+	case *company_service.GetByIdCompanyRequest:
+		for _, opt := range opts {
+			if header, ok := opt.(grpc.HeaderCallOption); ok && s.control != "" {
+				*header.HeaderAddr = metadata.Pairs("cache-control", s.control)
+			}
+		}
+		proto.Merge(reply.(proto.Message), &company_service.GetByIdCompanyResponse{Id: req.Id, Name: s.names[req.Id]})
+		if s.during != nil {
+			s.during()
+		}
+	case *company_service.UpdateCompanyRequest:
+		s.names[req.Id] = req.Name
+	}
+
+	return nil
+}
+
+// cacheStep reads company id or renames it, a read expects name, the cache result and whether
+// the backend was called
+type cacheStep struct {
+	read   string
+	update string
+	name   string
+	// control is the Cache-Control header of the request, backendControl the one of the response
+	control        string
+	backendControl string
+	fresh          bool
+
+	result string
+	called bool
+}
+
+func TestResponseCache(t *testing.T) {
+	const (
+		getByID = "/company_service.CompanyService/GetById"
+		update  = "/company_service.CompanyService/Update"
+	)
+
+	tests := []struct {
+		name     string
+		services []string
+		// invalidate renames company 1 once the first read has its stale reply
+		invalidate bool
+		steps      []cacheStep
+	}{
+		{
+			name:     "miss then hit",
+			services: []string{"company_service.CompanyService"},
+			steps: []cacheStep{
+				{read: "1", name: "a", result: CacheMiss, called: true},
+				{read: "1", name: "a", result: CacheHit},
+				{read: "2", name: "b", result: CacheMiss, called: true},
+			},
+		},
+		{
+			name:     "every service",
+			services: []string{AllServices},
+			steps: []cacheStep{
+				{read: "1", name: "a", result: CacheMiss, called: true},
+				{read: "1", name: "a", result: CacheHit},
+			},
+		},
+		{
+			name:     "service not cached",
+			services: []string{"position_service.PositionService"},
+			steps: []cacheStep{
+				{read: "1", name: "a", called: true},
+				{read: "1", name: "a", called: true},
+			},
+		},
+		{
+			name:     "update invalidates the service",
+			services: []string{AllServices},
+			steps: []cacheStep{
+				{read: "1", name: "a", result: CacheMiss, called: true},
+				{read: "2", name: "b", result: CacheMiss, called: true},
+				{update: "1", name: "c"},
+				{read: "1", name: "c", result: CacheMiss, called: true},
+				{read: "2", name: "b", result: CacheMiss, called: true},
+			},
+		},
+		{
+			name:     "no-cache revalidates and stores",
+			services: []string{AllServices},
+			steps: []cacheStep{
+				{read: "1", name: "a", result: CacheMiss, called: true},
+				{read: "1", name: "a", control: "no-cache", result: CacheMiss, called: true},
+				{read: "1", name: "a", result: CacheHit},
+			},
+		},
+		{
+			name:     "fresh reads like no-cache",
+			services: []string{AllServices},
+			steps: []cacheStep{
+				{read: "1", name: "a", fresh: true, result: CacheMiss, called: true},
+				{read: "1", name: "a", result: CacheHit},
+			},
+		},
+		{
+			name:     "no-store bypasses the cache",
+			services: []string{AllServices},
+			steps: []cacheStep{
+				{read: "1", name: "a", control: "no-store", called: true},
+				{read: "1", name: "a", result: CacheMiss, called: true},
+			},
+		},
+		{
+			name:     "max-age limits the age",
+			services: []string{AllServices},
+			steps: []cacheStep{
+				{read: "1", name: "a", result: CacheMiss, called: true},
+				{read: "1", name: "a", control: "max-age=60", result: CacheHit},
+				{read: "1", name: "a", control: "max-age=0", result: CacheMiss, called: true},
+			},
+		},
+		{
+			name:     "backend forbids storing",
+			services: []string{AllServices},
+			steps: []cacheStep{
+				{read: "1", name: "a", backendControl: "private", result: CacheMiss, called: true},
+				{read: "1", name: "a", backendControl: "no-store", result: CacheMiss, called: true},
+				{read: "1", name: "a", backendControl: "max-age=0", result: CacheMiss, called: true},
+				{read: "1", name: "a", backendControl: "max-age=60", result: CacheMiss, called: true},
+				{read: "1", name: "a", result: CacheHit},
+			},
+		},
+		{
+			name:       "response read during an invalidation is not stored",
+			services:   []string{AllServices},
+			invalidate: true,
+			steps: []cacheStep{
+				{read: "1", name: "a", result: CacheMiss, called: true},
+				{read: "1", name: "c", result: CacheMiss, called: true},
+				{read: "1", name: "c", result: CacheHit},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := NewResponseCache(CacheOptions{TTL: time.Minute, Services: tt.services, MaxEntries: 10})
+			store := &companyStore{names: map[string]string{"1": "a", "2": "b"}}
+			interceptor := rc.Interceptor()
+			if tt.invalidate {
+				store.during = func() {
+					store.during = nil
+					if err := interceptor(context.Background(), update, &company_service.UpdateCompanyRequest{Id: "1", Name: "c"},
+						&company_service.UpdateCompanyResponse{}, nil, store.invoke); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			for i, s := range tt.steps {
+				calls := store.calls
+				if s.update != "" {
+					err := interceptor(context.Background(), update, &company_service.UpdateCompanyRequest{Id: s.update, Name: s.name},
+						&company_service.UpdateCompanyResponse{}, nil, store.invoke)
+					if err != nil {
+						t.Fatalf("step %d: %v", i, err)
+					}
+					continue
+				}
+
+				ctx, status := WithCacheControl(context.Background(), s.control)
+				if s.fresh {
+					ctx = Fresh(ctx)
+				}
+				store.control = s.backendControl
+				reply := &company_service.GetByIdCompanyResponse{}
+				if err := interceptor(ctx, getByID, &company_service.GetByIdCompanyRequest{Id: s.read}, reply, nil, store.invoke); err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+
+				if reply.Name != s.name {
+					t.Errorf("step %d: name %q, want %q", i, reply.Name, s.name)
+				}
+				if result, _ := status.Result(); result != s.result {
+					t.Errorf("step %d: result %q, want %q", i, result, s.result)
+				}
+				if called := store.calls > calls; called != s.called {
+					t.Errorf("step %d: backend called %v, want %v", i, called, s.called)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheStatusResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []string
+		ages    []time.Duration
+		result  string
+		age     time.Duration
+	}{
+		{name: "no cached calls"},
+		{name: "hits", records: []string{CacheHit, CacheHit}, ages: []time.Duration{time.Second, 3 * time.Second},
+			result: CacheHit, age: 3 * time.Second},
+		{name: "a miss wins", records: []string{CacheHit, CacheMiss, CacheHit}, ages: []time.Duration{time.Second, 0, time.Second},
+			result: CacheMiss, age: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, status := WithCacheControl(context.Background(), "")
+			for i, result := range tt.records {
+				status.record(result, tt.ages[i])
+			}
+			if result, age := status.Result(); result != tt.result || age != tt.age {
+				t.Errorf("Result() = %q, %s, want %q, %s", result, age, tt.result, tt.age)
+			}
+		})
+	}
+}
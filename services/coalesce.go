@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xfirdavs/api_gateway/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Results of a coalesced call
+const (
+	coalesceUpstream  = "upstream"
+	coalesceShared    = "coalesced"
+	coalesceCancelled = "cancelled"
+)
+
+var coalesceRequestsTotal = metrics.NewCounter(
+	"grpc_coalesce_requests_total",
+	"Calls of coalesced gRPC methods per result: upstream started the backend call, coalesced joined "+
+		"one in flight, cancelled gave up before it ended",
+	"method", "result",
+)
+
+// Coalescer lets concurrent identical calls of the opted-in read methods share one backend call.
+// The shared call does not belong to any caller: a caller whose context ends gets its context
+// error and leaves the others waiting, the call is cancelled once every caller left.
+type Coalescer struct {
+	methods []string
+
+	mu    sync.Mutex
+	calls map[string]*sharedCall
+}
+
+// sharedCall is a backend call in flight and its result, set once done is closed
+type sharedCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	callers int
+
+	reply   proto.Message
+	header  metadata.MD
+	trailer metadata.MD
+	err     error
+}
+
+// NewCoalescer coalesces the read methods, GetAll and GetById, matching patterns: service/method,
+// service/* or *, e.g. company_service.CompanyService/GetById. It is nil without patterns.
+func NewCoalescer(patterns []string) *Coalescer {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	return &Coalescer{methods: patterns, calls: map[string]*sharedCall{}}
+}
+
+// Interceptor returns the client interceptor sharing the calls
+func (co *Coalescer) Interceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		reqMsg, reqOK := req.(proto.Message)
+		replyMsg, replyOK := reply.(proto.Message)
+		// a fresh read must not join a call that may have started before the caller's write
+		if !reqOK || !replyOK || !co.coalesced(method) || ctx.Value(freshKey{}) != nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		key, err := requestKey(method, reqMsg)
+		if err != nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		co.mu.Lock()
+		call, joined := co.calls[key]
+		if !joined {
+			callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			call = &sharedCall{done: make(chan struct{}), cancel: cancel, reply: replyMsg.ProtoReflect().New().Interface()}
+			co.calls[key] = call
+
+			callReq := proto.Clone(reqMsg)
+			callOpts := append(callerOptions(opts), grpc.Header(&call.header), grpc.Trailer(&call.trailer))
+			go co.run(key, call, func() error {
+				return invoker(callCtx, method, callReq, call.reply, cc, callOpts...)
+			})
+		}
+		call.callers++
+		co.mu.Unlock()
+
+		if joined {
+			coalesceRequestsTotal.Inc(method, coalesceShared)
+		} else {
+			coalesceRequestsTotal.Inc(method, coalesceUpstream)
+		}
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			co.leave(key, call)
+			coalesceRequestsTotal.Inc(method, coalesceCancelled)
+			return status.FromContextError(ctx.Err()).Err()
+		}
+
+		for _, opt := range opts {
+			switch o := opt.(type) {
+			case grpc.HeaderCallOption:
+				*o.HeaderAddr = call.header.Copy()
+			case grpc.TrailerCallOption:
+				*o.TrailerAddr = call.trailer.Copy()
+			}
+		}
+		if call.err != nil {
+			return call.err
+		}
+		proto.Merge(replyMsg, call.reply)
+
+		return nil
+	}
+}
+
+// run makes the backend call and hands its result to the callers
+func (co *Coalescer) run(key string, call *sharedCall, invoke func() error) {
+	call.err = invoke()
+	call.cancel()
+
+	co.mu.Lock()
+	if co.calls[key] == call {
+		delete(co.calls, key)
+	}
+	co.mu.Unlock()
+	close(call.done)
+}
+
+// leave drops a caller that gave up, the last one cancels the call and lets the next
+// identical call start a new one
+func (co *Coalescer) leave(key string, call *sharedCall) {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+
+	call.callers--
+	if call.callers > 0 {
+		return
+	}
+	call.cancel()
+	if co.calls[key] == call {
+		delete(co.calls, key)
+	}
+}
+
+// coalesced reports whether method is a read method matching a pattern, two identical
+// creates must each reach the backend
+func (co *Coalescer) coalesced(method string) bool {
+	service, name := splitMethod(method)
+	if !hasPrefix(name, readPrefixes) {
+		return false
+	}
+	for _, pattern := range co.methods {
+		if pattern == "*" || pattern == service+"/*" || pattern == service+"/"+name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// callerOptions drops the options writing into variables of the caller, the shared call
+// may still run when the caller has returned
+func callerOptions(opts []grpc.CallOption) []grpc.CallOption {
+	kept := make([]grpc.CallOption, 0, len(opts)+2)
+	for _, opt := range opts {
+		switch opt.(type) {
+		case grpc.HeaderCallOption, grpc.TrailerCallOption, grpc.PeerCallOption:
+			continue
+		}
+		kept = append(kept, opt)
+	}
+
+	return kept
+}
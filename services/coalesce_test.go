@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xfirdavs/api_gateway/genproto/company_service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// blockingInvoker answers every call with resp once release is closed and counts the calls
+type blockingInvoker struct {
+	calls   int32
+	release chan struct{}
+	resp    proto.Message
+}
+
+func (b *blockingInvoker) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+	atomic.AddInt32(&b.calls, 1)
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	proto.Merge(reply.(proto.Message), b.resp)
+
+	return nil
+}
+
+// callers returns the number of callers waiting for the call of req
+func (co *Coalescer) callers(method string, req proto.Message) int {
+	key, _ := requestKey(method, req)
+	co.mu.Lock()
+	defer co.mu.Unlock()
+	if call, ok := co.calls[key]; ok {
+		return call.callers
+	}
+
+	return 0
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoalescerSkipsWrites(t *testing.T) {
+	const method = "/company_service.CompanyService/Create"
+	co := NewCoalescer([]string{"*"})
+	invoker := &blockingInvoker{release: make(chan struct{}), resp: &company_service.Company{Id: "1", Name: "a"}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reply := &company_service.Company{}
+			if err := co.Interceptor()(context.Background(), method, &company_service.CreateCompanyRequest{Name: "a"}, reply, nil, invoker.invoke); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	// both creates reach the backend while the other is in flight
+	waitFor(t, func() bool { return atomic.LoadInt32(&invoker.calls) == 2 })
+	close(invoker.release)
+	wg.Wait()
+}
+
+func TestCoalescerCallerCancelled(t *testing.T) {
+	const method = "/company_service.CompanyService/GetById"
+	co := NewCoalescer([]string{"company_service.CompanyService/*"})
+	req := &company_service.GetByIdCompanyRequest{Id: "1"}
+	want := &company_service.GetByIdCompanyResponse{Id: "1", Name: "a"}
+	invoker := &blockingInvoker{release: make(chan struct{}), resp: want}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- co.Interceptor()(cancelled, method, proto.Clone(req), &company_service.GetByIdCompanyResponse{}, nil, invoker.invoke)
+	}()
+	waitFor(t, func() bool { return co.callers(method, req) == 1 })
+
+	reply := &company_service.GetByIdCompanyResponse{}
+	done := make(chan error, 1)
+	go func() {
+		done <- co.Interceptor()(context.Background(), method, proto.Clone(req), reply, nil, invoker.invoke)
+	}()
+	waitFor(t, func() bool { return co.callers(method, req) == 2 })
+
+	cancel()
+	if err := <-errs; status.Code(err) != codes.Canceled {
+		t.Fatalf("cancelled caller got %v, want Canceled", err)
+	}
+	close(invoker.release)
+	if err := <-done; err != nil {
+		t.Fatalf("remaining caller got %v", err)
+	}
+	if !proto.Equal(reply, want) {
+		t.Errorf("remaining caller got %v, want %v", reply, want)
+	}
+	if calls := atomic.LoadInt32(&invoker.calls); calls != 1 {
+		t.Errorf("%d backend calls, want 1", calls)
+	}
+}
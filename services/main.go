@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/xfirdavs/api_gateway/config"
 	"github.com/xfirdavs/api_gateway/genproto/company_service"
@@ -14,6 +15,8 @@ type ServiceManager interface {
 	AttributeService() position_service.AttributeServiceClient
 	PositionService() position_service.PositionServiceClient
 	CompanyService() company_service.CompanyServiceClient
+	Conn(service string) (grpc.ClientConnInterface, error)
+	Conns() []grpc.ClientConnInterface
 }
 
 type grpcClients struct {
@@ -21,19 +24,36 @@ type grpcClients struct {
 	professionService position_service.ProfessionServiceClient
 	positionService   position_service.PositionServiceClient
 	companyService    company_service.CompanyServiceClient
+
+	// conns holds the connection of each proto package, e.g. position_service
+	conns map[string]*grpc.ClientConn
 }
 
 // CompanyService implements ServiceManager
 
 func NewGrpcClients(conf *config.Config) (ServiceManager, error) {
+	// cache misses of identical requests are coalesced too
+	var interceptors []grpc.UnaryClientInterceptor
+	if responseCache := NewResponseCache(CacheOptions{
+		TTL:        conf.CacheTTL,
+		Services:   conf.CacheServices,
+		MaxEntries: conf.CacheMaxEntries,
+	}); responseCache != nil {
+		interceptors = append(interceptors, responseCache.Interceptor())
+	}
+	if coalescer := NewCoalescer(conf.CoalesceMethods); coalescer != nil {
+		interceptors = append(interceptors, coalescer.Interceptor())
+	}
+	opts := []grpc.DialOption{grpc.WithInsecure(), grpc.WithChainUnaryInterceptor(interceptors...)}
+
 	connPositionService, err := grpc.Dial(
 		fmt.Sprintf("%s:%d", conf.PositionServiceHost, conf.PositionServicePort),
-		grpc.WithInsecure())
+		opts...)
 	if err != nil {
 		return nil, err
 	}
 	connCompanyService, err := grpc.Dial(
-		fmt.Sprintf("%s:%d", conf.CompanyServiceHost, conf.CompanyServicePort), grpc.WithInsecure())
+		fmt.Sprintf("%s:%d", conf.CompanyServiceHost, conf.CompanyServicePort), opts...)
 
 	if err != nil {
 		return nil, err
@@ -44,6 +64,10 @@ func NewGrpcClients(conf *config.Config) (ServiceManager, error) {
 		professionService: position_service.NewProfessionServiceClient(connPositionService),
 		positionService:   position_service.NewPositionServiceClient(connPositionService),
 		companyService:    company_service.NewCompanyServiceClient(connCompanyService),
+		conns: map[string]*grpc.ClientConn{
+			"position_service": connPositionService,
+			"company_service":  connCompanyService,
+		},
 	}, nil
 }
 
@@ -61,3 +85,28 @@ func (g *grpcClients) AttributeService() position_service.AttributeServiceClient
 func (g *grpcClients) PositionService() position_service.PositionServiceClient {
 	return g.positionService
 }
+
+// Conn returns the connection serving the given full service name, e.g. company_service.CompanyService
+func (g *grpcClients) Conn(service string) (grpc.ClientConnInterface, error) {
+	pkg := service
+	if idx := strings.LastIndex(service, "."); idx >= 0 {
+		pkg = service[:idx]
+	}
+
+	conn, ok := g.conns[pkg]
+	if !ok {
+		return nil, fmt.Errorf("no connection for service %s", service)
+	}
+
+	return conn, nil
+}
+
+// Conns returns every backend connection
+func (g *grpcClients) Conns() []grpc.ClientConnInterface {
+	conns := make([]grpc.ClientConnInterface, 0, len(g.conns))
+	for _, conn := range g.conns {
+		conns = append(conns, conn)
+	}
+
+	return conns
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// refreshInterval limits how often an unknown service triggers a new reflection round
+const refreshInterval = 30 * time.Second
+
+// ErrUnknownMethod is returned by FindMethod when no backend serves the service or method,
+// its other errors mean that the descriptors or the connection could not be found
+var ErrUnknownMethod = errors.New("unknown method")
+
+// Resolver finds the descriptors of backend services that the gateway has no generated code for.
+// Descriptors come from a FileDescriptorSet file when one is given, otherwise from gRPC server reflection.
+type Resolver struct {
+	services ServiceManager
+	static   bool
+
+	mu          sync.RWMutex
+	files       *protoregistry.Files
+	conns       map[string]grpc.ClientConnInterface
+	refreshedAt time.Time
+	// refreshErr is the error of the last reflection round, returned for unknown methods until the next one
+	refreshErr error
+}
+
+// NewResolver creates a Resolver, descriptorSetFile may be empty to use server reflection
+func NewResolver(services ServiceManager, descriptorSetFile string) (*Resolver, error) {
+	r := &Resolver{
+		services: services,
+		files:    &protoregistry.Files{},
+		conns:    map[string]grpc.ClientConnInterface{},
+	}
+
+	if descriptorSetFile != "" {
+		files, err := loadDescriptorSet(descriptorSetFile)
+		if err != nil {
+			return nil, err
+		}
+		r.files, r.static = files, true
+	}
+
+	return r, nil
+}
+
+// FindMethod returns the descriptor of service/method and the connection serving it
+func (r *Resolver) FindMethod(ctx context.Context, service, method string) (protoreflect.MethodDescriptor, grpc.ClientConnInterface, error) {
+	md, conn, err := r.lookup(service, method)
+	if err == nil || r.static {
+		return md, conn, err
+	}
+
+	r.mu.Lock()
+	if time.Since(r.refreshedAt) < refreshInterval {
+		refreshErr := r.refreshErr
+		r.mu.Unlock()
+		if refreshErr != nil && errors.Is(err, ErrUnknownMethod) {
+			return nil, nil, refreshErr
+		}
+		return md, conn, err
+	}
+	r.refreshedAt = time.Now()
+	r.mu.Unlock()
+
+	err = r.refresh(ctx)
+	r.mu.Lock()
+	r.refreshErr = err
+	r.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.lookup(service, method)
+}
+
+func (r *Resolver) lookup(service, method string) (protoreflect.MethodDescriptor, grpc.ClientConnInterface, error) {
+	r.mu.RLock()
+	files, conn := r.files, r.conns[service]
+	r.mu.RUnlock()
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unknown service %s", ErrUnknownMethod, service)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s is not a service", ErrUnknownMethod, service)
+	}
+
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, nil, fmt.Errorf("%w %s/%s", ErrUnknownMethod, service, method)
+	}
+
+	if conn == nil {
+		if conn, err = r.services.Conn(service); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return md, conn, nil
+}
+
+// refresh asks every backend for its services over server reflection, errors of the backends
+// are returned as they are so that their status codes are kept
+func (r *Resolver) refresh(ctx context.Context) error {
+	fds := map[string]*descriptorpb.FileDescriptorProto{}
+	conns := map[string]grpc.ClientConnInterface{}
+
+	for _, conn := range r.services.Conns() {
+		services, err := reflectServices(ctx, conn, fds)
+		if err != nil {
+			return err
+		}
+		for _, service := range services {
+			conns[service] = conn
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range fds {
+		set.File = append(set.File, fd)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.files, r.conns = files, conns
+	r.mu.Unlock()
+
+	return nil
+}
+
+// reflectServices lists the services of one backend and collects their files, with dependencies, into fds
+func reflectServices(ctx context.Context, conn grpc.ClientConnInterface, fds map[string]*descriptorpb.FileDescriptorProto) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	resp, err := reflectionRequest(stream, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var services []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		if strings.HasPrefix(s.GetName(), "grpc.reflection.") {
+			continue
+		}
+		services = append(services, s.GetName())
+
+		resp, err := reflectionRequest(stream, &rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: s.GetName()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := addFiles(stream, resp, fds); err != nil {
+			return nil, err
+		}
+	}
+
+	return services, nil
+}
+
+func addFiles(stream rpb.ServerReflection_ServerReflectionInfoClient, resp *rpb.ServerReflectionResponse, fds map[string]*descriptorpb.FileDescriptorProto) error {
+	var received []*descriptorpb.FileDescriptorProto
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fd := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(raw, fd); err != nil {
+			return err
+		}
+		fds[fd.GetName()] = fd
+		received = append(received, fd)
+	}
+
+	for _, fd := range received {
+		for _, dep := range fd.GetDependency() {
+			if _, ok := fds[dep]; ok {
+				continue
+			}
+			if known, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+				addKnownFile(known, fds)
+				continue
+			}
+
+			resp, err := reflectionRequest(stream, &rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+			})
+			if err != nil {
+				return err
+			}
+			if err := addFiles(stream, resp, fds); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addKnownFile adds a file linked into the gateway, e.g. google/protobuf/empty.proto, with its imports
+func addKnownFile(fd protoreflect.FileDescriptor, fds map[string]*descriptorpb.FileDescriptorProto) {
+	if _, ok := fds[fd.Path()]; ok {
+		return
+	}
+
+	fds[fd.Path()] = protodesc.ToFileDescriptorProto(fd)
+	for i := 0; i < fd.Imports().Len(); i++ {
+		addKnownFile(fd.Imports().Get(i).FileDescriptor, fds)
+	}
+}
+
+func reflectionRequest(stream rpb.ServerReflection_ServerReflectionInfoClient, req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, fmt.Errorf("server reflection: %s", e.GetErrorMessage())
+	}
+
+	return resp, nil
+}
+
+func loadDescriptorSet(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("descriptor set %s: %w", path, err)
+	}
+
+	return protodesc.NewFiles(set)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xfirdavs/api_gateway/genproto/company_service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// downConn fails every call like a backend that can not be dialed
+type downConn struct{}
+
+func (downConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	return status.Error(codes.Unavailable, "connection refused")
+}
+
+func (downConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Error(codes.Unavailable, "connection refused")
+}
+
+// connManager serves every service of the package company_service with conn
+type connManager struct {
+	ServiceManager
+	conn grpc.ClientConnInterface
+}
+
+func (m connManager) Conn(service string) (grpc.ClientConnInterface, error) {
+	if service != company_service.CompanyService_ServiceDesc.ServiceName {
+		return nil, errors.New("no connection for service " + service)
+	}
+
+	return m.conn, nil
+}
+
+func (m connManager) Conns() []grpc.ClientConnInterface {
+	return []grpc.ClientConnInterface{m.conn}
+}
+
+func TestFindMethod(t *testing.T) {
+	fds := map[string]*descriptorpb.FileDescriptorProto{}
+	addKnownFile(company_service.File_company_proto, fds)
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range fds {
+		set.File = append(set.File, fd)
+	}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	descriptorSet := filepath.Join(t.TempDir(), "company.pb")
+	if err := os.WriteFile(descriptorSet, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	static, err := NewResolver(connManager{conn: downConn{}}, descriptorSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reflection, err := NewResolver(connManager{conn: downConn{}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		resolver *Resolver
+		service  string
+		method   string
+		// unknown expects ErrUnknownMethod, code the status code of another error
+		unknown bool
+		code    codes.Code
+	}{
+		{name: "known", resolver: static, service: "company_service.CompanyService", method: "GetById"},
+		{name: "unknown method", resolver: static, service: "company_service.CompanyService", method: "Drop", unknown: true},
+		{name: "unknown service", resolver: static, service: "company_service.Nothing", method: "GetById", unknown: true},
+		{name: "not a service", resolver: static, service: "company_service.Company", method: "GetById", unknown: true},
+		{name: "backend down", resolver: reflection, service: "company_service.CompanyService", method: "GetById", code: codes.Unavailable},
+		// the reflection round is not repeated within refreshInterval, its error is
+		{name: "backend still down", resolver: reflection, service: "company_service.CompanyService", method: "GetById", code: codes.Unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, conn, err := tt.resolver.FindMethod(context.Background(), tt.service, tt.method)
+			switch {
+			case tt.unknown:
+				if !errors.Is(err, ErrUnknownMethod) {
+					t.Errorf("error %v, want ErrUnknownMethod", err)
+				}
+			case tt.code != codes.OK:
+				if errors.Is(err, ErrUnknownMethod) || status.Code(err) != tt.code {
+					t.Errorf("error %v, want code %s", err, tt.code)
+				}
+			case err != nil:
+				t.Errorf("FindMethod: %v", err)
+			case string(md.Name()) != tt.method || conn == nil:
+				t.Errorf("found %s with connection %v", md.FullName(), conn)
+			}
+		})
+	}
+}